@@ -0,0 +1,159 @@
+package account
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testArgon2Params keep hashing fast in tests
+var testArgon2Params = argon2Params{memory: 64, time: 1, threads: 1, saltLen: 16, keyLen: 32}
+
+func newAuthService(t *testing.T) (*accountService, *TokenIssuer) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := NewTokenIssuer(key, "test", time.Minute, time.Hour)
+	s := NewAccountService(NewMemoryRepository(), nil, tokens).(*accountService)
+	s.argon2 = testArgon2Params
+	return s, tokens
+}
+
+func TestPasswordHash(t *testing.T) {
+	hash, err := hashPassword("correct horse", testArgon2Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := verifyPassword("correct horse", hash); err != nil || !ok {
+		t.Errorf("right password: got %v, %v", ok, err)
+	}
+	if ok, err := verifyPassword("battery staple", hash); err != nil || ok {
+		t.Errorf("wrong password: got %v, %v", ok, err)
+	}
+	if _, err := verifyPassword("correct horse", "$2a$10$notargon"); !errors.Is(err, errMalformedHash) {
+		t.Errorf("foreign hash: got %v, want errMalformedHash", err)
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newAuthService(t)
+
+	a, tokens, err := s.Register(ctx, "alice", " Alice@Example.com", "secret-password")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if a.Email != "alice@example.com" || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatalf("Register returned %+v, %+v", a, tokens)
+	}
+	if _, _, err := s.Register(ctx, "bob", "alice@example.com", "other-password"); !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("registering a taken email: got %v, want ErrDuplicateEmail", err)
+	}
+	if _, _, err := s.Register(ctx, "bob", "not-an-email", "other-password"); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("invalid email: got %v, want ErrInvalidEmail", err)
+	}
+	if _, _, err := s.Register(ctx, "bob", "bob@example.com", "short"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("short password: got %v, want ErrInvalidPassword", err)
+	}
+
+	got, _, err := s.Login(ctx, "ALICE@example.com", "secret-password")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if got.ID != a.ID {
+		t.Errorf("logged in as %s, want %s", got.ID, a.ID)
+	}
+	if _, _, err := s.Login(ctx, "alice@example.com", "wrong-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: got %v, want ErrInvalidCredentials", err)
+	}
+	if _, _, err := s.Login(ctx, "nobody@example.com", "secret-password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown email: got %v, want ErrInvalidCredentials", err)
+	}
+
+	if _, err := s.DeactivateAccount(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Login(ctx, "alice@example.com", "secret-password"); !errors.Is(err, ErrAccountDeactivated) {
+		t.Errorf("deactivated account: got %v, want ErrAccountDeactivated", err)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	s, tokens := newAuthService(t)
+	a, issued, err := s.Register(ctx, "alice", "alice@example.com", "secret-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, refreshed, err := s.RefreshToken(ctx, issued.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	if got.ID != a.ID || refreshed.AccessToken == "" {
+		t.Errorf("RefreshToken returned %+v, %+v", got, refreshed)
+	}
+	if _, _, err := s.RefreshToken(ctx, issued.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("access token used to refresh: got %v, want ErrInvalidToken", err)
+	}
+
+	tokens.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, _, err := s.RefreshToken(ctx, issued.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired refresh token: got %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestTokenKeyRotation(t *testing.T) {
+	_, oldKey, _ := ed25519.GenerateKey(nil)
+	_, newKey, _ := ed25519.GenerateKey(nil)
+	old := NewTokenIssuer(oldKey, "test", time.Minute, time.Hour)
+	issued, err := old.Issue(&Account{ID: "account"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := NewTokenIssuer(newKey, "test", time.Minute, time.Hour, oldKey.Public().(ed25519.PublicKey))
+	if id, err := rotated.ParseRefreshToken(context.Background(), issued.RefreshToken); err != nil || id != "account" {
+		t.Errorf("token of a retired key: got %q, %v", id, err)
+	}
+	other := NewTokenIssuer(newKey, "test", time.Minute, time.Hour)
+	if _, err := other.ParseRefreshToken(context.Background(), issued.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token of an unknown key: got %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestTokenVerifier(t *testing.T) {
+	ctx := context.Background()
+	_, key, _ := ed25519.GenerateKey(nil)
+	issuer := NewTokenIssuer(key, "test", time.Minute, time.Hour)
+	issued, err := issuer.Issue(&Account{ID: "account", Role: RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := issuer.PublicKeys()
+	verifier := NewTokenVerifier("test", func(ctx context.Context, kid string) (ed25519.PublicKey, error) {
+		key, ok := keys[kid]
+		if !ok {
+			return nil, errors.New("unknown key")
+		}
+		return key, nil
+	})
+	claims, err := verifier.ParseAccessToken(ctx, issued.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseAccessToken: %v", err)
+	}
+	if claims.Subject != "account" || claims.Role != RoleAdmin {
+		t.Errorf("got subject %q and role %q, want account and admin", claims.Subject, claims.Role)
+	}
+	if _, err := verifier.ParseAccessToken(ctx, issued.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh token used as access token: got %v, want ErrInvalidToken", err)
+	}
+	if _, err := NewTokenVerifier("other", verifier.keys).ParseAccessToken(ctx, issued.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token of another issuer: got %v, want ErrInvalidToken", err)
+	}
+}
@@ -2,9 +2,12 @@ package account
 
 import (
 	"context"
+	"crypto/ed25519"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	 pb "github.com/pawan-sharma-12/go_microservices/account/pb"
+	"github.com/pawan-sharma-12/go_microservices/auth"
+	"github.com/pawan-sharma-12/go_microservices/health"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 
 )
 
@@ -12,8 +15,10 @@ type Client struct {
 	conn *grpc.ClientConn
 	service pb.AccountServiceClient
 }
-func NewClient(url string) (*Client, error){
-	conn, err := grpc.Dial(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewClient connects to the account service. authn secures the connection and
+// signs the calls.
+func NewClient(url string, authn *auth.Authenticator) (*Client, error){
+	conn, err := grpc.Dial(url, append(authn.DialOptions(auth.ServiceAccount), tracing.DialOption())...)
 	if err != nil {
 		return nil, err
 	}
@@ -31,10 +36,7 @@ func  (c *Client) PostAccount(ctx context.Context, name string) (*Account, error
 	if err != nil {
 		return nil, err
 	}
-	return &Account{
-		ID:    r.Account.Id,
-		Name:  r.Account.Name,
-	}, nil
+	return convertProtoToAccount(r.Account), nil
 }
 
 func (c *Client) GetAccount(ctx context.Context, id string) (*Account, error) {
@@ -44,10 +46,23 @@ func (c *Client) GetAccount(ctx context.Context, id string) (*Account, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Account{
-		ID:    r.Account.Id,
-		Name:  r.Account.Name,
-	}, nil
+	return convertProtoToAccount(r.Account), nil
+}
+
+// GetAccountsByIDs fetches several accounts in one call. Unknown IDs are
+// left out of the result.
+func (c *Client) GetAccountsByIDs(ctx context.Context, ids []string) ([]Account, error) {
+	r, err := c.service.GetAccountsByIDs(ctx, &pb.GetAccountsByIDsRequest{
+		Ids: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]Account, 0, len(r.Accounts))
+	for _, a := range r.Accounts {
+		accounts = append(accounts, *convertProtoToAccount(a))
+	}
+	return accounts, nil
 }
 
 func (c *Client) GetAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error) {
@@ -60,13 +75,144 @@ func (c *Client) GetAccounts(ctx context.Context, skip uint64, take uint64) ([]A
 	}
 	accounts := make([]Account, 0, len(r.Accounts))
 	for _, a := range r.Accounts {
-		accounts = append(accounts, Account{
-			ID:    a.Id,
-			Name:  a.Name,
-		})
+		accounts = append(accounts, *convertProtoToAccount(a))
 	}
 	return accounts, nil
 }
+// GetAccountsPage fetches the accounts after the cursor. An empty cursor
+// starts at the first page.
+func (c *Client) GetAccountsPage(ctx context.Context, after string, first uint64) (*AccountPage, error) {
+	r, err := c.service.GetAccounts(ctx, &pb.GetAccountsRequest{
+		First: first,
+		After: after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	page := &AccountPage{
+		Accounts:    make([]Account, 0, len(r.Accounts)),
+		Cursors:     r.Cursors,
+		HasNextPage: r.HasNextPage,
+	}
+	for _, a := range r.Accounts {
+		page.Accounts = append(page.Accounts, *convertProtoToAccount(a))
+	}
+	return page, nil
+}
+
+func (c *Client) UpdateAccount(ctx context.Context, id string, name string) (*Account, error) {
+	r, err := c.service.UpdateAccount(ctx, &pb.UpdateAccountRequest{
+		Id:   id,
+		Name: name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertProtoToAccount(r.Account), nil
+}
+
+func (c *Client) DeactivateAccount(ctx context.Context, id string) (*Account, error) {
+	r, err := c.service.DeactivateAccount(ctx, &pb.DeactivateAccountRequest{
+		Id: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertProtoToAccount(r.Account), nil
+}
+
+func (c *Client) ReactivateAccount(ctx context.Context, id string) (*Account, error) {
+	r, err := c.service.ReactivateAccount(ctx, &pb.ReactivateAccountRequest{
+		Id: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertProtoToAccount(r.Account), nil
+}
+
+func (c *Client) DeleteAccount(ctx context.Context, id string) error {
+	_, err := c.service.DeleteAccount(ctx, &pb.DeleteAccountRequest{
+		Id: id,
+	})
+	return err
+}
+
+// Register creates an account with login credentials and returns it with
+// its first tokens
+func (c *Client) Register(ctx context.Context, name string, email string, password string) (*Account, *TokenPair, error) {
+	r, err := c.service.Register(ctx, &pb.RegisterRequest{
+		Name:     name,
+		Email:    email,
+		Password: password,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return convertProtoToAccount(r.Account), convertProtoToTokens(r.Tokens), nil
+}
+
+func (c *Client) Login(ctx context.Context, email string, password string) (*Account, *TokenPair, error) {
+	r, err := c.service.Login(ctx, &pb.LoginRequest{
+		Email:    email,
+		Password: password,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return convertProtoToAccount(r.Account), convertProtoToTokens(r.Tokens), nil
+}
+
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*Account, *TokenPair, error) {
+	r, err := c.service.RefreshToken(ctx, &pb.RefreshTokenRequest{
+		RefreshToken: refreshToken,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return convertProtoToAccount(r.Account), convertProtoToTokens(r.Tokens), nil
+}
+
+// GetPublicKeys fetches the keys that verify tokens issued by the account
+// service, by key ID
+func (c *Client) GetPublicKeys(ctx context.Context) (map[string]ed25519.PublicKey, error) {
+	r, err := c.service.GetPublicKeys(ctx, &pb.GetPublicKeysRequest{})
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]ed25519.PublicKey, len(r.Keys))
+	for _, k := range r.Keys {
+		if len(k.Key) != ed25519.PublicKeySize {
+			continue
+		}
+		keys[k.KeyId] = ed25519.PublicKey(k.Key)
+	}
+	return keys, nil
+}
+
+func convertProtoToAccount(a *pb.Account) *Account {
+	return &Account{
+		ID:     a.Id,
+		Name:   a.Name,
+		Status: AccountStatus(a.Status),
+		Email:  a.Email,
+		Role:   AccountRole(a.Role),
+	}
+}
+
+func convertProtoToTokens(t *pb.AuthTokens) *TokenPair {
+	return &TokenPair{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		ExpiresAt:    t.GetExpiresAt().AsTime(),
+	}
+}
+
 func (c *Client) Close() error {
 	return c.conn.Close()
-}
\ No newline at end of file
+}
+
+// Health reports whether the account service is serving
+func (c *Client) Health(ctx context.Context) error {
+	return health.Probe(ctx, c.conn, pb.AccountService_ServiceDesc.ServiceName)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"strconv"
@@ -9,6 +10,12 @@ import (
 	"github.com/avast/retry-go"
 	"github.com/joho/godotenv"
 	"github.com/pawan-sharma-12/go_microservices/account"
+	"github.com/pawan-sharma-12/go_microservices/account/config"
+	"github.com/pawan-sharma-12/go_microservices/auth"
+	"github.com/pawan-sharma-12/go_microservices/graceful"
+	"github.com/pawan-sharma-12/go_microservices/health"
+	"github.com/pawan-sharma-12/go_microservices/order"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 )
 type Config struct{
 	DatabaseURL string `envconfig:"DATABASE_URL,required"`
@@ -21,6 +28,11 @@ func main(){
 	}
 	_ = godotenv.Load(".env." + env)
 
+	flushSpans, err := tracing.Setup(context.Background(), auth.ServiceAccount)
+	if err != nil {
+		log.Fatalf("Could not set up tracing: %v", err)
+	}
+
 	cfg := Config{
 		DatabaseURL: os.Getenv("ACCOUNT_DATABASE_URL"),
 	}
@@ -29,7 +41,7 @@ func main(){
 	}
 	log.Println("Using Database URL:", cfg.DatabaseURL)
 	var r account.Repository
-	err := retry.Do(
+	err = retry.Do(
 		func() error {
 			var err error
 			r, err = account.NewPostgresRepository(cfg.DatabaseURL)
@@ -45,7 +57,6 @@ func main(){
 	if err != nil {
 		log.Fatalf("💥 Could not establish database connection after retries: %v", err)
 	}
-	defer r.Close()
 	
 	portStr := os.Getenv("ACCOUNT_SERVICE_PORT")
 	if portStr == "" {
@@ -56,8 +67,53 @@ func main(){
 		log.Fatalf("Invalid port number: %v", err)
 	}
 	
+	orderURL := os.Getenv("ORDER_SERVICE_URL")
+	if orderURL == "" {
+		log.Fatal("ORDER_SERVICE_URL not set")
+	}
+	authn, err := auth.NewAuthenticatorFromEnv(auth.ServiceAccount)
+	if err != nil {
+		log.Fatalf("Invalid service auth config: %v", err)
+	}
+	orderClient, err := order.NewClient(orderURL, authn)
+	if err != nil {
+		log.Fatalf("Could not create order client: %v", err)
+	}
+
+	authCfg, err := config.LoadAuth()
+	if err != nil {
+		log.Fatalf("Invalid auth config: %v", err)
+	}
+	if authCfg.SigningKeyFile == "" {
+		log.Println("⚠️ AUTH_SIGNING_KEY_FILE not set, signing tokens with a temporary key")
+	}
+	signingKey, err := authCfg.SigningKey()
+	if err != nil {
+		log.Fatalf("Could not load signing key: %v", err)
+	}
+	retiredKeys, err := authCfg.VerificationKeys()
+	if err != nil {
+		log.Fatalf("Could not load verification keys: %v", err)
+	}
+	tokens := account.NewTokenIssuer(signingKey, authCfg.Issuer, authCfg.AccessTokenTTL, authCfg.RefreshTokenTTL, retiredKeys...)
+
+	if err := graceful.TimeoutFromEnv(); err != nil {
+		log.Fatalf("Invalid shutdown timeout: %v", err)
+	}
+	ctx, stop := graceful.SignalContext()
+	defer stop()
+
 	log.Println("Account Service Listening at port", portInt)
-	s := account.NewAccountService(r)
-	log.Fatal(account.ListenAndServeGRPC(s, portInt))
+	s := account.NewAccountService(r, orderClient, tokens)
+	err = account.ListenAndServeGRPC(ctx, s, portInt, authn, health.Checks{"postgres": r.Ping})
+
+	// The server has drained: close the clients, then the database
+	orderClient.Close()
+	r.Close()
+	flushSpans()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("👋 Account service stopped")
 	
 }
\ No newline at end of file
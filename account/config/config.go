@@ -1,9 +1,14 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -16,6 +21,24 @@ type Config struct {
 	Env         string     `yaml:"env" env:"ENV" env-required:"true" env-default:"production"`
 	DatabaseURL string     `yaml:"database_url" env:"DATABASE_URL" env-required:"true"`
 	HttpServer  HttpServer `yaml:"http_server"`
+	Auth        Auth       `yaml:"auth"`
+}
+
+// Auth configures the tokens issued by the account service. Tokens are
+// signed with Ed25519 so that other services can check them with the public
+// key alone.
+type Auth struct {
+	// SigningKeyFile is a PEM file holding the PKCS #8 Ed25519 private key
+	// new tokens are signed with. When empty a random key is made at start,
+	// so tokens do not survive a restart.
+	SigningKeyFile string `yaml:"signing_key_file" env:"AUTH_SIGNING_KEY_FILE"`
+	// VerificationKeyFiles are PEM files holding the PKIX public keys of
+	// retired signing keys. Tokens they signed stay valid until they expire,
+	// which allows rotating the signing key without logging everyone out.
+	VerificationKeyFiles []string      `yaml:"verification_key_files" env:"AUTH_VERIFICATION_KEY_FILES"`
+	Issuer               string        `yaml:"issuer" env:"AUTH_ISSUER" env-default:"go_microservices/account"`
+	AccessTokenTTL       time.Duration `yaml:"access_token_ttl" env:"AUTH_ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTokenTTL      time.Duration `yaml:"refresh_token_ttl" env:"AUTH_REFRESH_TOKEN_TTL" env-default:"168h"`
 }
 
 func MustLoad() *Config {
@@ -41,3 +64,69 @@ func MustLoad() *Config {
 
 	return &cfg
 }
+
+// LoadAuth reads the auth settings from the environment alone, for services
+// started without a config file
+func LoadAuth() (*Auth, error) {
+	var auth Auth
+	if err := cleanenv.ReadEnv(&auth); err != nil {
+		return nil, err
+	}
+	return &auth, nil
+}
+
+// SigningKey loads the private key from SigningKeyFile. It returns a new
+// random key when no file is configured.
+func (a *Auth) SigningKey() (ed25519.PrivateKey, error) {
+	if a.SigningKeyFile == "" {
+		_, key, err := ed25519.GenerateKey(nil)
+		return key, err
+	}
+	block, err := readPEM(a.SigningKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", a.SigningKeyFile, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 private key", a.SigningKeyFile)
+	}
+	return key, nil
+}
+
+// VerificationKeys loads the public keys from VerificationKeyFiles
+func (a *Auth) VerificationKeys() ([]ed25519.PublicKey, error) {
+	keys := make([]ed25519.PublicKey, 0, len(a.VerificationKeyFiles))
+	for _, path := range a.VerificationKeyFiles {
+		block, err := readPEM(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an Ed25519 public key", path)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Helper: read the first PEM block of a file
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,173 @@
+package account
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// memoryRepository keeps accounts in memory. It is safe for concurrent use
+// and behaves like the Postgres repository, so it can stand in for it in
+// tests and local runs.
+type memoryRepository struct {
+	mu       sync.RWMutex
+	accounts map[string]Account
+	// passwordHashes holds the password hash of each account that has one
+	passwordHashes map[string]string
+}
+
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		accounts:       make(map[string]Account),
+		passwordHashes: make(map[string]string),
+	}
+}
+
+func (r *memoryRepository) Close() {}
+
+func (r *memoryRepository) Ping(ctx context.Context) error { return nil }
+
+func (r *memoryRepository) PutAccount(ctx context.Context, account Account) error {
+	return r.PutAccountWithPassword(ctx, account, "")
+}
+
+func (r *memoryRepository) PutAccountWithPassword(ctx context.Context, account Account, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.accounts[account.ID]; ok {
+		return ErrDuplicateID
+	}
+	if account.Email != "" {
+		for _, a := range r.accounts {
+			if a.Email == account.Email {
+				return ErrDuplicateEmail
+			}
+		}
+	}
+	r.accounts[account.ID] = account
+	if passwordHash != "" {
+		r.passwordHashes[account.ID] = passwordHash
+	}
+	return nil
+}
+
+func (r *memoryRepository) GetAccountByEmail(ctx context.Context, email string) (*Account, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, a := range r.accounts {
+		if email != "" && a.Email == email {
+			return &a, r.passwordHashes[a.ID], nil
+		}
+	}
+	return nil, "", ErrNotFound
+}
+
+func (r *memoryRepository) GetAccountByID(ctx context.Context, id string) (*Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &account, nil
+}
+
+func (r *memoryRepository) GetAccountsByIDs(ctx context.Context, ids []string) ([]Account, error) {
+	r.mu.RLock()
+	accounts := []Account{}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if account, ok := r.accounts[id]; ok && !seen[id] {
+			seen[id] = true
+			accounts = append(accounts, account)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].ID < accounts[j].ID
+	})
+	return accounts, nil
+}
+
+// ListAccounts returns accounts ordered by ID, newest first, like the
+// Postgres query does for KSUIDs
+func (r *memoryRepository) ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error) {
+	r.mu.RLock()
+	accounts := make([]Account, 0, len(r.accounts))
+	for _, a := range r.accounts {
+		accounts = append(accounts, a)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].ID > accounts[j].ID
+	})
+	return page(accounts, skip, take), nil
+}
+
+func (r *memoryRepository) ListAccountsAfter(ctx context.Context, afterID string, first uint64) ([]Account, error) {
+	r.mu.RLock()
+	accounts := []Account{}
+	for _, a := range r.accounts {
+		if afterID == "" || a.ID < afterID {
+			accounts = append(accounts, a)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].ID > accounts[j].ID
+	})
+	if first < uint64(len(accounts)) {
+		accounts = accounts[:first]
+	}
+	return accounts, nil
+}
+
+func (r *memoryRepository) UpdateAccountName(ctx context.Context, id string, name string) (*Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	account.Name = name
+	r.accounts[id] = account
+	return &account, nil
+}
+
+func (r *memoryRepository) SetAccountStatus(ctx context.Context, id string, status AccountStatus) (*Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	account.Status = status
+	r.accounts[id] = account
+	return &account, nil
+}
+
+func (r *memoryRepository) DeleteAccount(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.accounts[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.accounts, id)
+	delete(r.passwordHashes, id)
+	return nil
+}
+
+// Helper: apply OFFSET/LIMIT to an already ordered slice
+func page[T any](items []T, skip uint64, take uint64) []T {
+	if skip >= uint64(len(items)) {
+		return nil
+	}
+	items = items[skip:]
+	if take < uint64(len(items)) {
+		items = items[:take]
+	}
+	return items
+}
@@ -0,0 +1,5 @@
+-- Login credentials. Accounts created before this migration have neither
+-- and cannot log in. Emails are stored lower-cased.
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS email VARCHAR(254) UNIQUE,
+    ADD COLUMN IF NOT EXISTS password_hash TEXT;
@@ -0,0 +1,5 @@
+-- Roles checked by the gateway. Admins are made by hand:
+-- UPDATE accounts SET role = 'admin' WHERE email = '...';
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'admin'));
@@ -0,0 +1,77 @@
+package account
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2Params are the Argon2id settings new hashes are made with. They
+// follow the second recommended option of RFC 9106 (64 MiB, 3 passes).
+// Hashes record their own parameters, so raising these later does not break
+// existing passwords.
+type argon2Params struct {
+	memory  uint32 // KiB
+	time    uint32
+	threads uint8
+	saltLen int
+	keyLen  uint32
+}
+
+var defaultArgon2Params = argon2Params{
+	memory:  64 * 1024,
+	time:    3,
+	threads: 4,
+	saltLen: 16,
+	keyLen:  32,
+}
+
+var errMalformedHash = errors.New("malformed password hash")
+
+// hashPassword hashes a password with Argon2id into the PHC string format,
+// e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+func hashPassword(password string, p argon2Params) (string, error) {
+	salt := make([]byte, p.saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, p.keyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.time, p.threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// verifyPassword reports whether password matches a hash made by
+// hashPassword. The comparison takes constant time.
+func verifyPassword(password string, hash string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, errMalformedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, errMalformedHash
+	}
+	var p argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return false, errMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, errMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, errMalformedHash
+	}
+
+	got := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
@@ -0,0 +1,229 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/ksuid"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewMemoryRepository()
+	})
+}
+
+// TestPostgresRepository runs the conformance suite against a real database.
+// Point ACCOUNT_TEST_DATABASE_URL at a disposable database with up.sql applied.
+func TestPostgresRepository(t *testing.T) {
+	url := os.Getenv("ACCOUNT_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("ACCOUNT_TEST_DATABASE_URL not set")
+	}
+	testRepository(t, func(t *testing.T) Repository {
+		repo, err := NewPostgresRepository(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	})
+}
+
+// testRepository checks the behaviour every Repository implementation must
+// share. Tests only look at accounts they created, so the backend may
+// already hold other data.
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	newAccount := func(t *testing.T, repo Repository, name string) Account {
+		t.Helper()
+		a := Account{ID: ksuid.New().String(), Name: name, Status: StatusActive, Role: RoleUser}
+		if err := repo.PutAccount(ctx, a); err != nil {
+			t.Fatalf("PutAccount: %v", err)
+		}
+		return a
+	}
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		a := newAccount(t, repo, "alice")
+
+		got, err := repo.GetAccountByID(ctx, a.ID)
+		if err != nil {
+			t.Fatalf("GetAccountByID: %v", err)
+		}
+		if *got != a {
+			t.Errorf("got %+v, want %+v", *got, a)
+		}
+	})
+
+	t.Run("GetByIDs", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		a := newAccount(t, repo, "alice")
+		b := newAccount(t, repo, "bob")
+		newAccount(t, repo, "carol")
+
+		got, err := repo.GetAccountsByIDs(ctx, []string{b.ID, ksuid.New().String(), a.ID, b.ID})
+		if err != nil {
+			t.Fatalf("GetAccountsByIDs: %v", err)
+		}
+		want := []Account{a, b}
+		if a.ID > b.ID {
+			want = []Account{b, a}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("PutDuplicateID", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		a := newAccount(t, repo, "alice")
+
+		if err := repo.PutAccount(ctx, a); !errors.Is(err, ErrDuplicateID) {
+			t.Errorf("got %v, want ErrDuplicateID", err)
+		}
+	})
+
+	t.Run("Credentials", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		email := strings.ToLower(ksuid.New().String()) + "@example.com"
+		a := Account{ID: ksuid.New().String(), Name: "alice", Status: StatusActive, Email: email, Role: RoleUser}
+		if err := repo.PutAccountWithPassword(ctx, a, "hash"); err != nil {
+			t.Fatalf("PutAccountWithPassword: %v", err)
+		}
+
+		got, hash, err := repo.GetAccountByEmail(ctx, email)
+		if err != nil {
+			t.Fatalf("GetAccountByEmail: %v", err)
+		}
+		if *got != a || hash != "hash" {
+			t.Errorf("got %+v with hash %q, want %+v with hash %q", *got, hash, a, "hash")
+		}
+		if got, err := repo.GetAccountByID(ctx, a.ID); err != nil || got.Email != email {
+			t.Errorf("GetAccountByID: got %+v, %v, want email %s", got, err, email)
+		}
+
+		b := Account{ID: ksuid.New().String(), Name: "bob", Status: StatusActive, Email: email, Role: RoleUser}
+		if err := repo.PutAccountWithPassword(ctx, b, "other"); !errors.Is(err, ErrDuplicateEmail) {
+			t.Errorf("reusing an email: got %v, want ErrDuplicateEmail", err)
+		}
+		if _, _, err := repo.GetAccountByEmail(ctx, "nobody-"+email); !errors.Is(err, ErrNotFound) {
+			t.Errorf("unknown email: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		id := ksuid.New().String()
+
+		if _, err := repo.GetAccountByID(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetAccountByID: got %v, want ErrNotFound", err)
+		}
+		if _, err := repo.UpdateAccountName(ctx, id, "bob"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateAccountName: got %v, want ErrNotFound", err)
+		}
+		if _, err := repo.SetAccountStatus(ctx, id, StatusDeactivated); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetAccountStatus: got %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteAccount(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteAccount: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		a := newAccount(t, repo, "alice")
+
+		got, err := repo.UpdateAccountName(ctx, a.ID, "bob")
+		if err != nil {
+			t.Fatalf("UpdateAccountName: %v", err)
+		}
+		if got.Name != "bob" || got.Status != StatusActive {
+			t.Errorf("after rename got %+v", *got)
+		}
+		got, err = repo.SetAccountStatus(ctx, a.ID, StatusDeactivated)
+		if err != nil {
+			t.Fatalf("SetAccountStatus: %v", err)
+		}
+		if got.Name != "bob" || got.Status != StatusDeactivated {
+			t.Errorf("after deactivation got %+v", *got)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		a := newAccount(t, repo, "alice")
+
+		if err := repo.DeleteAccount(ctx, a.ID); err != nil {
+			t.Fatalf("DeleteAccount: %v", err)
+		}
+		if _, err := repo.GetAccountByID(ctx, a.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ListOrderAndPagination", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		for i := 0; i < 3; i++ {
+			newAccount(t, repo, "list")
+		}
+
+		all, err := repo.ListAccounts(ctx, 0, 1000)
+		if err != nil {
+			t.Fatalf("ListAccounts: %v", err)
+		}
+		for i := 1; i < len(all); i++ {
+			if all[i-1].ID < all[i].ID {
+				t.Fatalf("accounts not ordered by ID descending: %s before %s", all[i-1].ID, all[i].ID)
+			}
+		}
+
+		page, err := repo.ListAccounts(ctx, 1, 2)
+		if err != nil {
+			t.Fatalf("ListAccounts: %v", err)
+		}
+		if len(page) != 2 {
+			t.Fatalf("got %d accounts, want 2", len(page))
+		}
+		for i, a := range page {
+			if a.ID != all[i+1].ID {
+				t.Errorf("page[%d] = %s, want %s", i, a.ID, all[i+1].ID)
+			}
+		}
+
+		after, err := repo.ListAccountsAfter(ctx, all[0].ID, 2)
+		if err != nil {
+			t.Fatalf("ListAccountsAfter: %v", err)
+		}
+		if len(after) != 2 || after[0].ID != all[1].ID || after[1].ID != all[2].ID {
+			t.Errorf("ListAccountsAfter(%s) = %+v, want %s then %s", all[0].ID, after, all[1].ID, all[2].ID)
+		}
+		start, err := repo.ListAccountsAfter(ctx, "", 1)
+		if err != nil {
+			t.Fatalf("ListAccountsAfter: %v", err)
+		}
+		if len(start) != 1 || start[0].ID != all[0].ID {
+			t.Errorf("ListAccountsAfter from the start = %+v, want %s", start, all[0].ID)
+		}
+
+		past, err := repo.ListAccounts(ctx, uint64(len(all)), 10)
+		if err != nil {
+			t.Fatalf("ListAccounts: %v", err)
+		}
+		if len(past) != 0 {
+			t.Errorf("got %d accounts past the end, want 0", len(past))
+		}
+	})
+}
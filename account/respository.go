@@ -3,14 +3,40 @@ package account
 import (
 	"context"
 	"database/sql"
+	"errors"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 )
+
+var (
+	ErrNotFound       = errors.New("entity not found")
+	ErrDuplicateID    = errors.New("duplicate id")
+	ErrDuplicateEmail = errors.New("email already registered")
+)
+
+// accountColumns are the columns scanned into an Account. Accounts created
+// before credentials were added have no email.
+const accountColumns = "id, name, status, COALESCE(email, ''), role"
+
 type Repository interface {
 	Close()
+	// Ping checks that the database answers
+	Ping(ctx context.Context) error
 	PutAccount(ctx context.Context, account Account) error
+	// PutAccountWithPassword stores a new account together with the hash of
+	// its password. account.Email must be set and unused.
+	PutAccountWithPassword(ctx context.Context, account Account, passwordHash string) error
+	// GetAccountByEmail returns the account registered with email and its
+	// password hash
+	GetAccountByEmail(ctx context.Context, email string) (*Account, string, error)
 	GetAccountByID(ctx context.Context, id string) (*Account, error)
+	GetAccountsByIDs(ctx context.Context, ids []string) ([]Account, error)
 	ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error)
+	ListAccountsAfter(ctx context.Context, afterID string, first uint64) ([]Account, error)
+	UpdateAccountName(ctx context.Context, id string, name string) (*Account, error)
+	SetAccountStatus(ctx context.Context, id string, status AccountStatus) (*Account, error)
+	DeleteAccount(ctx context.Context, id string) error
 }
 
 type postgresRepository struct {
@@ -18,7 +44,7 @@ type postgresRepository struct {
 }
 
 func NewPostgresRepository(url string) (Repository, error) {
-    db, err := sql.Open("postgres", url)
+    db, err := tracing.OpenPostgres(url)
     if err != nil {
         return nil, err
     }
@@ -35,25 +61,62 @@ func NewPostgresRepository(url string) (Repository, error) {
 func (r *postgresRepository) Close() {
 	r.db.Close()
 }
-func (r *postgresRepository) Ping() error {
-	return r.db.Ping()
+func (r *postgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
 }
 
 func (r *postgresRepository) PutAccount(ctx context.Context, account Account) error {
-	_, err := r.db.ExecContext(ctx, "INSERT INTO accounts (id, name) VALUES ($1, $2)", account.ID, account.Name)
-	return err
+	_, err := r.db.ExecContext(ctx, "INSERT INTO accounts (id, name, status, email, role) VALUES ($1, $2, $3, NULLIF($4, ''), $5)", account.ID, account.Name, account.Status, account.Email, account.Role)
+	return putAccountError(err)
+
+}
 
+func (r *postgresRepository) PutAccountWithPassword(ctx context.Context, account Account, passwordHash string) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO accounts (id, name, status, email, role, password_hash) VALUES ($1, $2, $3, $4, $5, $6)", account.ID, account.Name, account.Status, account.Email, account.Role, passwordHash)
+	return putAccountError(err)
+}
+
+func (r *postgresRepository) GetAccountByEmail(ctx context.Context, email string) (*Account, string, error) {
+	var (
+		account Account
+		hash    sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx, "SELECT "+accountColumns+", password_hash FROM accounts WHERE email = $1", email).
+		Scan(&account.ID, &account.Name, &account.Status, &account.Email, &account.Role, &hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", err
+	}
+	return &account, hash.String, nil
 }
 func (r *postgresRepository) GetAccountByID(ctx context.Context, id string) (*Account, error) {
-	row := r.db.QueryRowContext(ctx, "SELECT id, name FROM accounts WHERE id = $1", id)
-	var account Account
-	if err := row.Scan(&account.ID, &account.Name); err != nil {
+	row := r.db.QueryRowContext(ctx, "SELECT "+accountColumns+" FROM accounts WHERE id = $1", id)
+	return scanAccount(row)
+}
+// GetAccountsByIDs returns the accounts with the given IDs, ordered by ID.
+// Unknown IDs are left out.
+func (r *postgresRepository) GetAccountsByIDs(ctx context.Context, ids []string) ([]Account, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+accountColumns+" FROM accounts WHERE id = ANY($1) ORDER BY id", pq.Array(ids))
+	if err != nil {
 		return nil, err
 	}
-	return &account, nil
+	defer rows.Close()
+
+	accounts := []Account{}
+	for rows.Next() {
+		var account Account
+		if err := rows.Scan(&account.ID, &account.Name, &account.Status, &account.Email, &account.Role); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
 }
+
 func (r *postgresRepository) ListAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error) {
-	rows, err := r.db.QueryContext(ctx, "SELECT id, name FROM accounts ORDER BY id DESC  OFFSET $1 LIMIT $2", skip, take)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+accountColumns+" FROM accounts ORDER BY id DESC  OFFSET $1 LIMIT $2", skip, take)
 	if err != nil {
 		return nil, err
 	}
@@ -62,10 +125,86 @@ func (r *postgresRepository) ListAccounts(ctx context.Context, skip uint64, take
 	var accounts []Account
 	for rows.Next() {
 		var account Account
-		if err := rows.Scan(&account.ID, &account.Name); err != nil {
+		if err := rows.Scan(&account.ID, &account.Name, &account.Status, &account.Email, &account.Role); err != nil {
 			continue
 		}
 		accounts = append(accounts, account)
 	}
 	return accounts, nil
 }
+
+// ListAccountsAfter returns up to first accounts that come after afterID in
+// ListAccounts order. An empty afterID starts from the beginning.
+func (r *postgresRepository) ListAccountsAfter(ctx context.Context, afterID string, first uint64) ([]Account, error) {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if afterID == "" {
+		rows, err = r.db.QueryContext(ctx, "SELECT "+accountColumns+" FROM accounts ORDER BY id DESC LIMIT $1", first)
+	} else {
+		rows, err = r.db.QueryContext(ctx, "SELECT "+accountColumns+" FROM accounts WHERE id < $1 ORDER BY id DESC LIMIT $2", afterID, first)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []Account{}
+	for rows.Next() {
+		var account Account
+		if err := rows.Scan(&account.ID, &account.Name, &account.Status, &account.Email, &account.Role); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+func (r *postgresRepository) UpdateAccountName(ctx context.Context, id string, name string) (*Account, error) {
+	row := r.db.QueryRowContext(ctx, "UPDATE accounts SET name = $2 WHERE id = $1 RETURNING "+accountColumns, id, name)
+	return scanAccount(row)
+}
+
+func (r *postgresRepository) SetAccountStatus(ctx context.Context, id string, status AccountStatus) (*Account, error) {
+	row := r.db.QueryRowContext(ctx, "UPDATE accounts SET status = $2 WHERE id = $1 RETURNING "+accountColumns, id, status)
+	return scanAccount(row)
+}
+
+func (r *postgresRepository) DeleteAccount(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM accounts WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Helper: map unique violations of an account insert to repository errors
+func putAccountError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		if pqErr.Constraint == "accounts_email_key" {
+			return ErrDuplicateEmail
+		}
+		return ErrDuplicateID
+	}
+	return err
+}
+
+func scanAccount(row *sql.Row) (*Account, error) {
+	var account Account
+	if err := row.Scan(&account.ID, &account.Name, &account.Status, &account.Email, &account.Role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
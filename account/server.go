@@ -5,12 +5,21 @@ package account
 
 import (
 	"context"
+	"errors"
 	"net"
 	"google.golang.org/grpc"
 	"fmt"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	 pb "github.com/pawan-sharma-12/go_microservices/account/pb"
+	"github.com/pawan-sharma-12/go_microservices/auth"
+	"github.com/pawan-sharma-12/go_microservices/cursor"
+	"github.com/pawan-sharma-12/go_microservices/graceful"
+	"github.com/pawan-sharma-12/go_microservices/health"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 
 )
 
@@ -19,32 +28,54 @@ type grpcServer struct {
 	pb.UnimplementedAccountServiceServer
 
 }
-func ListenAndServeGRPC(service Service, port int) error {
+// accessPolicy says which callers may use each RPC. Everything goes through
+// the gateway, except the order service looking up accounts. Changes to an
+// account are made for a user, and the handlers check it owns the account
+// or is an admin.
+var accessPolicy = auth.Policy{
+	pb.AccountService_PostAccount_FullMethodName:       {Services: []string{auth.ServiceGateway}},
+	pb.AccountService_GetAccount_FullMethodName:        {Services: []string{auth.ServiceGateway, auth.ServiceOrder}},
+	pb.AccountService_GetAccounts_FullMethodName:       {Services: []string{auth.ServiceGateway}},
+	pb.AccountService_GetAccountsByIDs_FullMethodName:  {Services: []string{auth.ServiceGateway}},
+	pb.AccountService_UpdateAccount_FullMethodName:     {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleUser, auth.RoleAdmin}},
+	pb.AccountService_DeactivateAccount_FullMethodName: {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleUser, auth.RoleAdmin}},
+	pb.AccountService_ReactivateAccount_FullMethodName: {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleUser, auth.RoleAdmin}},
+	pb.AccountService_DeleteAccount_FullMethodName:     {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleUser, auth.RoleAdmin}},
+	pb.AccountService_Register_FullMethodName:          {Services: []string{auth.ServiceGateway}},
+	pb.AccountService_Login_FullMethodName:             {Services: []string{auth.ServiceGateway}},
+	pb.AccountService_RefreshToken_FullMethodName:      {Services: []string{auth.ServiceGateway}},
+	pb.AccountService_GetPublicKeys_FullMethodName:     {Services: []string{auth.ServiceGateway}},
+}
+
+// ListenAndServeGRPC serves the account service until ctx is done, then drains
+// it. Callers are checked with authn against accessPolicy. The health
+// service reports it serving while checks pass.
+func ListenAndServeGRPC(ctx context.Context, service Service, port int, authn *auth.Authenticator, checks health.Checks) error {
 	// Implementation for starting gRPC server goes here
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return err
 	}
-	grpcSrv := grpc.NewServer()
+	grpcSrv := grpc.NewServer(append(authn.ServerOptions(accessPolicy), tracing.ServerOption())...)
 	pb.RegisterAccountServiceServer(grpcSrv,  &grpcServer{
 		service: service,
 		UnimplementedAccountServiceServer : pb.UnimplementedAccountServiceServer{},
 		
 	} )
+	healthSrv := health.NewServer(ctx, pb.AccountService_ServiceDesc.ServiceName, checks)
+	defer healthSrv.Stop()
+	healthSrv.Register(grpcSrv)
 	reflection.Register(grpcSrv)
-	return grpcSrv.Serve(lis)
+	return graceful.ServeGRPC(ctx, grpcSrv, lis)
 }
 //POST /accounts
 func (s *grpcServer) PostAccount(ctx context.Context, req *pb.PostAccountRequest) (*pb.PostAccountResponse, error) {
 	account, err := s.service.PostAccount(ctx, req.Name)
 	if err != nil {
-		return nil, err
+		return nil, accountError(req.Name, err)
 	}
 	return &pb.PostAccountResponse{
-		Account : &pb.Account{
-			Id:    account.ID,
-			Name:  account.Name,
-		}, 
+		Account : convertAccountToProto(account),
 	}, nil
 }
 
@@ -52,32 +83,236 @@ func (s *grpcServer) PostAccount(ctx context.Context, req *pb.PostAccountRequest
 func (s *grpcServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest)(*pb.GetAccountResponse, error){
 	account, err := s.service.GetAccountByID(ctx, req.Id)
 	if err != nil {
-		return nil, err
+		return nil, accountError(req.Id, err)
 	}
 	return &pb.GetAccountResponse{
-		Account : &pb.Account{
-			Id:    account.ID,
-			Name:  account.Name,
-		},
+		Account : convertAccountToProto(account),
 
 	}, nil
 }
 
+//GET /accounts?ids=...
+func (s *grpcServer) GetAccountsByIDs(ctx context.Context, req *pb.GetAccountsByIDsRequest) (*pb.GetAccountsByIDsResponse, error) {
+	accounts, err := s.service.GetAccountsByIDs(ctx, req.Ids)
+	if err != nil {
+		return nil, unexpectedError(err, "failed to get accounts")
+	}
+	pbAccounts := make([]*pb.Account, 0, len(accounts))
+	for _, account := range accounts {
+		pbAccounts = append(pbAccounts, convertAccountToProto(&account))
+	}
+	return &pb.GetAccountsByIDsResponse{
+		Accounts: pbAccounts,
+	}, nil
+}
+
 //GET /accounts
 func (s * grpcServer) GetAccounts(ctx context.Context, req *pb.GetAccountsRequest)(*pb.GetAccountsResponse, error){
+	if req.First > 0 || req.After != "" {
+		return s.getAccountsPage(ctx, req)
+	}
 	accounts, err := s.service.GetAccounts(ctx, req.Skip, req.Take)
 	if err != nil {
-		return nil, err
+		return nil, unexpectedError(err, "failed to list accounts")
 	}
 	var pbAccounts []*pb.Account
 	for _, account := range accounts {
-		pbAccounts = append(pbAccounts, &pb.Account{
-			Id:    account.ID,
-			Name:  account.Name,
-		})
+		pbAccounts = append(pbAccounts, convertAccountToProto(&account))
 	}
 	return &pb.GetAccountsResponse{
 		Accounts: pbAccounts,
 	}, nil
 }
 
+// Helper: cursor-paginated variant of GetAccounts
+func (s *grpcServer) getAccountsPage(ctx context.Context, req *pb.GetAccountsRequest) (*pb.GetAccountsResponse, error) {
+	page, err := s.service.GetAccountsPage(ctx, req.After, req.First)
+	if err != nil {
+		if errors.Is(err, cursor.ErrInvalid) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, unexpectedError(err, "failed to list accounts")
+	}
+	pbAccounts := make([]*pb.Account, 0, len(page.Accounts))
+	for _, account := range page.Accounts {
+		pbAccounts = append(pbAccounts, convertAccountToProto(&account))
+	}
+	return &pb.GetAccountsResponse{
+		Accounts:    pbAccounts,
+		Cursors:     page.Cursors,
+		HasNextPage: page.HasNextPage,
+	}, nil
+}
+
+//PATCH /accounts/{id}
+func (s *grpcServer) UpdateAccount(ctx context.Context, req *pb.UpdateAccountRequest) (*pb.UpdateAccountResponse, error) {
+	if err := auth.RequireOwner(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	account, err := s.service.UpdateAccount(ctx, req.Id, req.Name)
+	if err != nil {
+		return nil, accountError(req.Id, err)
+	}
+	return &pb.UpdateAccountResponse{
+		Account: convertAccountToProto(account),
+	}, nil
+}
+
+//POST /accounts/{id}/deactivate
+func (s *grpcServer) DeactivateAccount(ctx context.Context, req *pb.DeactivateAccountRequest) (*pb.DeactivateAccountResponse, error) {
+	if err := auth.RequireOwner(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	account, err := s.service.DeactivateAccount(ctx, req.Id)
+	if err != nil {
+		return nil, accountError(req.Id, err)
+	}
+	return &pb.DeactivateAccountResponse{
+		Account: convertAccountToProto(account),
+	}, nil
+}
+
+//POST /accounts/{id}/reactivate
+func (s *grpcServer) ReactivateAccount(ctx context.Context, req *pb.ReactivateAccountRequest) (*pb.ReactivateAccountResponse, error) {
+	if err := auth.RequireOwner(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	account, err := s.service.ReactivateAccount(ctx, req.Id)
+	if err != nil {
+		return nil, accountError(req.Id, err)
+	}
+	return &pb.ReactivateAccountResponse{
+		Account: convertAccountToProto(account),
+	}, nil
+}
+
+//DELETE /accounts/{id}
+func (s *grpcServer) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	if err := auth.RequireOwner(ctx, req.Id); err != nil {
+		return nil, err
+	}
+	if err := s.service.DeleteAccount(ctx, req.Id); err != nil {
+		return nil, accountError(req.Id, err)
+	}
+	return &pb.DeleteAccountResponse{}, nil
+}
+
+//POST /auth/register
+func (s *grpcServer) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
+	account, tokens, err := s.service.Register(ctx, req.Name, req.Email, req.Password)
+	if err != nil {
+		return nil, authError(err)
+	}
+	return &pb.RegisterResponse{
+		Account: convertAccountToProto(account),
+		Tokens:  convertTokensToProto(tokens),
+	}, nil
+}
+
+//POST /auth/login
+func (s *grpcServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	account, tokens, err := s.service.Login(ctx, req.Email, req.Password)
+	if err != nil {
+		return nil, authError(err)
+	}
+	return &pb.LoginResponse{
+		Account: convertAccountToProto(account),
+		Tokens:  convertTokensToProto(tokens),
+	}, nil
+}
+
+//POST /auth/refresh
+func (s *grpcServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	account, tokens, err := s.service.RefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		return nil, authError(err)
+	}
+	return &pb.RefreshTokenResponse{
+		Account: convertAccountToProto(account),
+		Tokens:  convertTokensToProto(tokens),
+	}, nil
+}
+
+//GET /auth/keys
+func (s *grpcServer) GetPublicKeys(ctx context.Context, req *pb.GetPublicKeysRequest) (*pb.GetPublicKeysResponse, error) {
+	keys, err := s.service.GetPublicKeys(ctx)
+	if err != nil {
+		return nil, unexpectedError(err, "failed to get public keys")
+	}
+	resp := &pb.GetPublicKeysResponse{}
+	for kid, key := range keys {
+		resp.Keys = append(resp.Keys, &pb.PublicKey{
+			KeyId: kid,
+			Key:   key,
+		})
+	}
+	return resp, nil
+}
+
+// authError maps registration and login errors to status codes. Credential
+// and token failures share one message so they tell nothing about which
+// part was wrong.
+func authError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidName), errors.Is(err, ErrInvalidEmail), errors.Is(err, ErrInvalidPassword):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrDuplicateEmail):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, ErrAccountDeactivated):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return unexpectedError(err, "authentication failed")
+	}
+}
+
+func accountError(id string, err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Errorf(codes.NotFound, "account %q not found", id)
+	case errors.Is(err, ErrInvalidName):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrDuplicateID):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, ErrHasOpenOrders):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return unexpectedError(err, "account request failed")
+	}
+}
+
+// unexpectedError hides errors no case above knows, such as database
+// errors, behind a generic message. Statuses, as from the order service,
+// are passed on.
+func unexpectedError(err error, message string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, message+": timed out")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, message+": canceled")
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+func convertAccountToProto(account *Account) *pb.Account {
+	return &pb.Account{
+		Id:     account.ID,
+		Name:   account.Name,
+		Status: string(account.Status),
+		Email:  account.Email,
+		Role:   string(account.Role),
+	}
+}
+
+func convertTokensToProto(tokens *TokenPair) *pb.AuthTokens {
+	return &pb.AuthTokens{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    timestamppb.New(tokens.ExpiresAt),
+	}
+}
@@ -0,0 +1,33 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAccountError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{ErrNotFound, codes.NotFound},
+		{ErrHasOpenOrders, codes.FailedPrecondition},
+		{fmt.Errorf("checking open orders: %w", status.Error(codes.Unavailable, "order service down")), codes.Unavailable},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{errors.New("sql: connection is already closed"), codes.Internal},
+	}
+	for _, tt := range tests {
+		got := status.Convert(accountError("a1", tt.err))
+		if got.Code() != tt.code {
+			t.Errorf("%v: got %s, want %s", tt.err, got.Code(), tt.code)
+		}
+		if tt.code == codes.Internal && got.Message() != "account request failed" {
+			t.Errorf("%v: internal error leaks %q", tt.err, got.Message())
+		}
+	}
+}
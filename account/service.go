@@ -2,35 +2,130 @@ package account
 import
  ( 
 		"context"
+		"crypto/ed25519"
+		"errors"
+		"fmt"
+		"log"
+		"net/mail"
+		"strings"
+		"sync"
+ 		"github.com/pawan-sharma-12/go_microservices/cursor"
  		"github.com/segmentio/ksuid"
 )
 
 type Service interface {
 	PostAccount(ctx context.Context, name string) (*Account, error)
 	GetAccountByID  (ctx context.Context, id string) (*Account, error)
+	GetAccountsByIDs(ctx context.Context, ids []string) ([]Account, error)
 	GetAccounts (ctx context.Context, skip uint64, take uint64) ([]Account, error)
+	GetAccountsPage(ctx context.Context, after string, first uint64) (*AccountPage, error)
+	UpdateAccount(ctx context.Context, id string, name string) (*Account, error)
+	DeactivateAccount(ctx context.Context, id string) (*Account, error)
+	ReactivateAccount(ctx context.Context, id string) (*Account, error)
+	DeleteAccount(ctx context.Context, id string) error
+	Register(ctx context.Context, name string, email string, password string) (*Account, *TokenPair, error)
+	Login(ctx context.Context, email string, password string) (*Account, *TokenPair, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*Account, *TokenPair, error)
+	GetPublicKeys(ctx context.Context) (map[string]ed25519.PublicKey, error)
 
 }
 
+type AccountStatus string
+
+const (
+	StatusActive      AccountStatus = "active"
+	StatusDeactivated AccountStatus = "deactivated"
+)
+
+// AccountRole decides what an account may do through the gateway
+type AccountRole string
+
+const (
+	RoleUser  AccountRole = "user"
+	RoleAdmin AccountRole = "admin"
+)
+
+// maxNameLength matches the accounts.name column
+const maxNameLength = 25
+
+// maxPageSize caps how many accounts one page returns
+const maxPageSize = 100
+
+// Password and email length limits. maxEmailLength matches the
+// accounts.email column.
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 256
+	maxEmailLength    = 254
+)
+
+var (
+	ErrInvalidName        = errors.New("invalid account name")
+	ErrHasOpenOrders      = errors.New("account has open orders")
+	ErrInvalidEmail       = errors.New("invalid email")
+	ErrInvalidPassword    = errors.New("invalid password")
+	ErrInvalidCredentials = errors.New("wrong email or password")
+	ErrAccountDeactivated = errors.New("account is deactivated")
+)
 
 type Account struct {
 	ID	string `json:"id"`
 	Name	string `json:"name"`
+	Status	AccountStatus `json:"status"`
+	// Email is empty for accounts made without credentials
+	Email	string `json:"email,omitempty"`
+	Role	AccountRole `json:"role"`
 }
+
+// AccountPage is one page of a cursor-paginated account listing
+type AccountPage struct {
+	Accounts []Account
+	// Cursors[i] is the position just after Accounts[i]
+	Cursors     []string
+	HasNextPage bool
+}
+
+// accountCursor is the position encoded in account page cursors
+type accountCursor struct {
+	ID string `json:"id"`
+}
+
+// OrderChecker reports whether an account still has orders in progress.
+// It is implemented by the order service client.
+type OrderChecker interface {
+	HasOpenOrders(ctx context.Context, accountID string) (bool, error)
+}
+
 type accountService struct {
 	repo Repository
+	orders OrderChecker
+	tokens *TokenIssuer
+	argon2 argon2Params
+
+	// dummyHash is checked against on logins with an unknown email, so
+	// they take as long as those with a wrong password
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
-func NewAccountService(repo Repository) Service {
+func NewAccountService(repo Repository, orders OrderChecker, tokens *TokenIssuer) Service {
 	return &accountService{
 		repo: repo,
+		orders: orders,
+		tokens: tokens,
+		argon2: defaultArgon2Params,
 	}
 }
 
 func (s *accountService) PostAccount(ctx context.Context, name string) (*Account, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
 	account := Account{
 		ID:    ksuid.New().String(),
 		Name:  name,
+		Status: StatusActive,
+		Role:   RoleUser,
 	}
 	if err := s.repo.PutAccount(ctx, account); err != nil {
 		return nil, err
@@ -40,9 +135,211 @@ func (s *accountService) PostAccount(ctx context.Context, name string) (*Account
 func (s *accountService) GetAccountByID(ctx context.Context, id string) (*Account, error) {
 	return s.repo.GetAccountByID(ctx, id)
 }
+// GetAccountsByIDs fetches several accounts at once. Unknown IDs are left
+// out of the result.
+func (s *accountService) GetAccountsByIDs(ctx context.Context, ids []string) ([]Account, error) {
+	if len(ids) == 0 {
+		return []Account{}, nil
+	}
+	return s.repo.GetAccountsByIDs(ctx, ids)
+}
 func (s *accountService) GetAccounts(ctx context.Context, skip uint64, take uint64) ([]Account, error) {
 	if take > 100 || (skip == 0 && take == 0) {
 		take = 100
 	}
 	return s.repo.ListAccounts(ctx, skip, take)
-}
\ No newline at end of file
+}
+
+// GetAccountsPage lists accounts after the given cursor, newest first.
+// Unlike GetAccounts it does not slow down on deep pages.
+func (s *accountService) GetAccountsPage(ctx context.Context, after string, first uint64) (*AccountPage, error) {
+	if first == 0 || first > maxPageSize {
+		first = maxPageSize
+	}
+	var position accountCursor
+	if after != "" {
+		if err := cursor.Decode(after, &position); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra row tells whether there is a next page
+	accounts, err := s.repo.ListAccountsAfter(ctx, position.ID, first+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &AccountPage{HasNextPage: uint64(len(accounts)) > first}
+	if page.HasNextPage {
+		accounts = accounts[:first]
+	}
+	page.Accounts = accounts
+	page.Cursors = make([]string, len(accounts))
+	for i, a := range accounts {
+		page.Cursors[i] = cursor.Encode(accountCursor{ID: a.ID})
+	}
+	return page, nil
+}
+
+func (s *accountService) UpdateAccount(ctx context.Context, id string, name string) (*Account, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateAccountName(ctx, id, name)
+}
+
+func (s *accountService) DeactivateAccount(ctx context.Context, id string) (*Account, error) {
+	return s.repo.SetAccountStatus(ctx, id, StatusDeactivated)
+}
+
+func (s *accountService) ReactivateAccount(ctx context.Context, id string) (*Account, error) {
+	return s.repo.SetAccountStatus(ctx, id, StatusActive)
+}
+
+// DeleteAccount removes an account for good. It is refused while the order
+// service still has open orders for it. The account is deactivated before
+// the check, so that no order can be placed for it between the check and
+// the delete; it is reactivated if the delete is refused.
+func (s *accountService) DeleteAccount(ctx context.Context, id string) error {
+	a, err := s.repo.GetAccountByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if a.Status != StatusDeactivated {
+		if _, err := s.repo.SetAccountStatus(ctx, id, StatusDeactivated); err != nil {
+			return err
+		}
+	}
+
+	open, err := s.orders.HasOpenOrders(ctx, id)
+	if err == nil && !open {
+		return s.repo.DeleteAccount(ctx, id)
+	}
+	if a.Status != StatusDeactivated {
+		if _, restoreErr := s.repo.SetAccountStatus(context.WithoutCancel(ctx), id, a.Status); restoreErr != nil {
+			log.Printf("Error reactivating account %s after refused delete: %v", id, restoreErr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("checking open orders: %w", err)
+	}
+	return ErrHasOpenOrders
+}
+
+// Register creates an account that can log in and logs it in
+func (s *accountService) Register(ctx context.Context, name string, email string, password string) (*Account, *TokenPair, error) {
+	if err := validateName(name); err != nil {
+		return nil, nil, err
+	}
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, nil, err
+	}
+	hash, err := hashPassword(password, s.argon2)
+	if err != nil {
+		return nil, nil, err
+	}
+	account := Account{
+		ID:     ksuid.New().String(),
+		Name:   name,
+		Status: StatusActive,
+		Email:  email,
+		Role:   RoleUser,
+	}
+	if err := s.repo.PutAccountWithPassword(ctx, account, hash); err != nil {
+		return nil, nil, err
+	}
+	return s.issue(&account)
+}
+
+// Login checks an email and password and hands out new tokens. Unknown
+// emails and wrong passwords fail alike.
+func (s *accountService) Login(ctx context.Context, email string, password string) (*Account, *TokenPair, error) {
+	account, hash, err := s.repo.GetAccountByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, ErrNotFound) {
+		account, hash = nil, s.getDummyHash()
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if hash == "" {
+		// An account without a password cannot log in
+		hash = s.getDummyHash()
+	}
+	ok, err := verifyPassword(password, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok || account == nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if account.Status == StatusDeactivated {
+		return nil, nil, ErrAccountDeactivated
+	}
+	return s.issue(account)
+}
+
+// RefreshToken trades a valid refresh token for new tokens, as long as the
+// account still exists and is active
+func (s *accountService) RefreshToken(ctx context.Context, refreshToken string) (*Account, *TokenPair, error) {
+	id, err := s.tokens.ParseRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	account, err := s.repo.GetAccountByID(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil, ErrInvalidToken
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if account.Status == StatusDeactivated {
+		return nil, nil, ErrAccountDeactivated
+	}
+	return s.issue(account)
+}
+
+// GetPublicKeys returns the keys that verify issued tokens, by key ID
+func (s *accountService) GetPublicKeys(ctx context.Context) (map[string]ed25519.PublicKey, error) {
+	return s.tokens.PublicKeys(), nil
+}
+
+// Helper: sign tokens for an account
+func (s *accountService) issue(account *Account) (*Account, *TokenPair, error) {
+	tokens, err := s.tokens.Issue(account)
+	if err != nil {
+		return nil, nil, err
+	}
+	return account, tokens, nil
+}
+
+// Helper: the hash of a password nobody has, made on first use
+func (s *accountService) getDummyHash() string {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = hashPassword(ksuid.New().String(), s.argon2)
+	})
+	return s.dummyHash
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > maxEmailLength {
+		return "", fmt.Errorf("%w: %q", ErrInvalidEmail, email)
+	}
+	return email, nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return fmt.Errorf("%w: must be %d to %d characters", ErrInvalidPassword, minPasswordLength, maxPasswordLength)
+	}
+	return nil
+}
+
+func validateName(name string) error {
+	if name == "" || len(name) > maxNameLength {
+		return fmt.Errorf("%w: must be 1 to %d characters", ErrInvalidName, maxNameLength)
+	}
+	return nil
+}
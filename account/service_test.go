@@ -0,0 +1,69 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// orderCheckerFunc adapts a function to OrderChecker
+type orderCheckerFunc func(ctx context.Context, accountID string) (bool, error)
+
+func (f orderCheckerFunc) HasOpenOrders(ctx context.Context, accountID string) (bool, error) {
+	return f(ctx, accountID)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+	errUnavailable := errors.New("order service unavailable")
+	tests := []struct {
+		name       string
+		open       bool
+		checkErr   error
+		wantErr    error
+		wantStatus AccountStatus
+	}{
+		{"no open orders", false, nil, nil, ""},
+		{"open orders", true, nil, ErrHasOpenOrders, StatusActive},
+		{"check fails", false, errUnavailable, errUnavailable, StatusActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			var statusAtCheck AccountStatus
+			s := NewAccountService(repo, orderCheckerFunc(func(ctx context.Context, id string) (bool, error) {
+				a, err := repo.GetAccountByID(ctx, id)
+				if err != nil {
+					return false, err
+				}
+				statusAtCheck = a.Status
+				return tt.open, tt.checkErr
+			}), nil)
+			a, err := s.PostAccount(ctx, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.DeleteAccount(ctx, a.ID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteAccount: got %v, want %v", err, tt.wantErr)
+			}
+			if statusAtCheck != StatusDeactivated {
+				t.Errorf("account was %q while checking orders, want deactivated", statusAtCheck)
+			}
+
+			got, err := repo.GetAccountByID(ctx, a.ID)
+			if tt.wantStatus == "" {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("account still there: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("status: got %q, want %q", got.Status, tt.wantStatus)
+			}
+		})
+	}
+}
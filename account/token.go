@@ -0,0 +1,190 @@
+package account
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/segmentio/ksuid"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Token uses, kept in the token_use claim so that a refresh token cannot
+// be passed off as an access token or the other way round
+const (
+	TokenUseAccess  = "access"
+	TokenUseRefresh = "refresh"
+)
+
+// TokenPair is what a successful login hands out
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresAt is when the access token expires
+	ExpiresAt time.Time
+}
+
+// Claims are the JWT claims of issued tokens. The subject is the account ID.
+type Claims struct {
+	jwt.RegisteredClaims
+	TokenUse string      `json:"token_use"`
+	Role     AccountRole `json:"role,omitempty"`
+}
+
+// KeyFunc returns the public key named by the kid header of a token
+type KeyFunc func(ctx context.Context, kid string) (ed25519.PublicKey, error)
+
+// TokenVerifier checks tokens issued by the account service. Other services
+// build one over the keys returned by GetPublicKeys.
+type TokenVerifier struct {
+	issuer string
+	keys   KeyFunc
+	now    func() time.Time
+}
+
+func NewTokenVerifier(issuer string, keys KeyFunc) *TokenVerifier {
+	return &TokenVerifier{
+		issuer: issuer,
+		keys:   keys,
+		now:    time.Now,
+	}
+}
+
+// ParseAccessToken checks an access token and returns its claims
+func (v *TokenVerifier) ParseAccessToken(ctx context.Context, token string) (*Claims, error) {
+	return v.parse(ctx, token, TokenUseAccess)
+}
+
+// Helper: verify a token's signature, issuer, lifetime and use
+func (v *TokenVerifier) parse(ctx context.Context, token string, use string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(v.now),
+	)
+	if err != nil || claims.TokenUse != use || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// TokenIssuer signs access and refresh tokens with an Ed25519 key and
+// checks the refresh tokens it gets back
+type TokenIssuer struct {
+	key        ed25519.PrivateKey
+	keyID      string
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	// publicKeys holds the public half of key and those of retired keys,
+	// by key ID
+	publicKeys map[string]ed25519.PublicKey
+	verifier   *TokenVerifier
+	now        func() time.Time
+}
+
+// NewTokenIssuer returns an issuer signing with key. Tokens signed by one of
+// the retired keys are still accepted.
+func NewTokenIssuer(key ed25519.PrivateKey, issuer string, accessTTL time.Duration, refreshTTL time.Duration, retired ...ed25519.PublicKey) *TokenIssuer {
+	public := key.Public().(ed25519.PublicKey)
+	t := &TokenIssuer{
+		key:        key,
+		keyID:      KeyID(public),
+		issuer:     issuer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		publicKeys: map[string]ed25519.PublicKey{KeyID(public): public},
+		now:        time.Now,
+	}
+	for _, k := range retired {
+		t.publicKeys[KeyID(k)] = k
+	}
+	t.verifier = &TokenVerifier{
+		issuer: issuer,
+		keys: func(ctx context.Context, kid string) (ed25519.PublicKey, error) {
+			key, ok := t.publicKeys[kid]
+			if !ok {
+				return nil, errors.New("unknown signing key")
+			}
+			return key, nil
+		},
+		now: func() time.Time { return t.now() },
+	}
+	return t
+}
+
+// PublicKeys returns the keys tokens are checked with, by key ID: that of
+// the signing key and those of retired keys
+func (t *TokenIssuer) PublicKeys() map[string]ed25519.PublicKey {
+	keys := make(map[string]ed25519.PublicKey, len(t.publicKeys))
+	for kid, key := range t.publicKeys {
+		keys[kid] = key
+	}
+	return keys
+}
+
+// KeyID names a public key in the kid header of the tokens it verifies
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+// Issue signs a new access and refresh token for an account
+func (t *TokenIssuer) Issue(account *Account) (*TokenPair, error) {
+	now := t.now()
+	access, err := t.sign(account, TokenUseAccess, now, t.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := t.sign(account, TokenUseRefresh, now, t.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresAt:    now.Add(t.accessTTL),
+	}, nil
+}
+
+// ParseRefreshToken checks a refresh token and returns the ID of its account
+func (t *TokenIssuer) ParseRefreshToken(ctx context.Context, token string) (string, error) {
+	claims, err := t.verifier.parse(ctx, token, TokenUseRefresh)
+	if err != nil {
+		return "", err
+	}
+	return claims.Subject, nil
+}
+
+// Helper: sign a token of one use for an account. The role is only put in
+// access tokens; a refresh picks up the account's current role.
+func (t *TokenIssuer) sign(account *Account, use string, now time.Time, ttl time.Duration) (string, error) {
+	var role AccountRole
+	if use == TokenUseAccess {
+		role = account.Role
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        ksuid.New().String(),
+			Issuer:    t.issuer,
+			Subject:   account.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		TokenUse: use,
+		Role:     role,
+	})
+	token.Header["kid"] = t.keyID
+	return token.SignedString(t.key)
+}
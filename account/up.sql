@@ -2,3 +2,15 @@ CREATE TABLE IF NOT EXISTS accounts (
     id CHAR(27) PRIMARY KEY,
     name VARCHAR(25) NOT NULL
 );
+
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'deactivated'));
+
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS email VARCHAR(254) UNIQUE,
+    ADD COLUMN IF NOT EXISTS password_hash TEXT;
+
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'admin'));
@@ -0,0 +1,332 @@
+// Package auth authenticates calls between the services. Every client signs
+// a short-lived service token with its own Ed25519 key, naming the calling
+// service, the service called and, for calls the gateway makes for a
+// logged-in user, that user's account and role. Servers check the token
+// against the caller's public key and let the call through only if the
+// RPC's rule allows the caller. Connections are plaintext, TLS or mutual
+// TLS, see TLSConfig.
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Names services sign their tokens with
+const (
+	ServiceAccount = "account"
+	ServiceCatalog = "catalog"
+	ServiceOrder   = "order"
+	ServiceGateway = "graphql"
+)
+
+// Roles of end users, as in account.AccountRole
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+const (
+	tokenTTL    = time.Minute
+	tokenLeeway = 30 * time.Second
+	// reflectionPrefix starts the methods of the gRPC reflection service,
+	// which only describe the API and are open to all
+	reflectionPrefix = "/grpc.reflection."
+	// healthPrefix starts the methods of the gRPC health service, open to
+	// all so that probes need no token
+	healthPrefix = "/grpc.health.v1."
+)
+
+var ErrInvalidToken = errors.New("invalid service token")
+
+// Identity is who made a call: a service, and the end user it acts for if
+// any
+type Identity struct {
+	Service   string
+	AccountID string
+	Role      string
+}
+
+// Rule says who may call one RPC
+type Rule struct {
+	// Services that may make the call
+	Services []string
+	// Roles the end user must have, if set. Calls without a user are
+	// refused.
+	Roles []string
+}
+
+func (r Rule) allows(id *Identity) bool {
+	if !slices.Contains(r.Services, id.Service) {
+		return false
+	}
+	return len(r.Roles) == 0 || slices.Contains(r.Roles, id.Role)
+}
+
+// Policy holds the rule of every RPC of a server, by full method name.
+// Calls to methods it does not list are refused.
+type Policy map[string]Rule
+
+type identityKey struct{}
+type userKey struct{}
+
+// FromContext returns the identity of the caller of the current RPC
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
+
+// RequireOwner checks that the current RPC is made for the account
+// accountID, or for an admin. Calls made for no user are refused.
+func RequireOwner(ctx context.Context, accountID string) error {
+	id, ok := FromContext(ctx)
+	if !ok || id.AccountID == "" {
+		return status.Error(codes.PermissionDenied, "call is not made for a user")
+	}
+	if id.Role != RoleAdmin && id.AccountID != accountID {
+		return status.Errorf(codes.PermissionDenied, "account %s may not act for account %s", id.AccountID, accountID)
+	}
+	return nil
+}
+
+// WithUser marks outgoing calls made with ctx as made for an end user
+func WithUser(ctx context.Context, accountID string, role string) context.Context {
+	return context.WithValue(ctx, userKey{}, &Identity{AccountID: accountID, Role: role})
+}
+
+type serviceClaims struct {
+	jwt.RegisteredClaims
+	AccountID string `json:"account_id,omitempty"`
+	Role      string `json:"role,omitempty"`
+}
+
+// Authenticator signs the tokens of one service's outgoing calls and checks
+// those of its incoming calls. Each service signs with its own private key,
+// so a service can only ever speak for itself.
+type Authenticator struct {
+	service string
+	key     ed25519.PrivateKey
+	// peers holds the public key of every service whose calls are checked,
+	// by service name
+	peers map[string]ed25519.PublicKey
+	now   func() time.Time
+
+	serverCreds credentials.TransportCredentials
+	clientCreds credentials.TransportCredentials
+}
+
+func NewAuthenticator(service string, key ed25519.PrivateKey, peers map[string]ed25519.PublicKey) (*Authenticator, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("service auth key is not an Ed25519 private key")
+	}
+	return &Authenticator{
+		service:     service,
+		key:         key,
+		peers:       peers,
+		now:         time.Now,
+		serverCreds: insecure.NewCredentials(),
+		clientCreds: insecure.NewCredentials(),
+	}, nil
+}
+
+// NewAuthenticatorFromEnv reads the private key of the service from the PEM
+// file SERVICE_AUTH_KEY_FILE, the public keys of its callers from
+// SERVICE_AUTH_PUBLIC_KEY_DIR (see LoadPublicKeys) and the TLS settings as
+// TLSConfigFromEnv does
+func NewAuthenticatorFromEnv(service string) (*Authenticator, error) {
+	keyFile := os.Getenv("SERVICE_AUTH_KEY_FILE")
+	if keyFile == "" {
+		return nil, errors.New("SERVICE_AUTH_KEY_FILE not set")
+	}
+	keyDir := os.Getenv("SERVICE_AUTH_PUBLIC_KEY_DIR")
+	if keyDir == "" {
+		return nil, errors.New("SERVICE_AUTH_PUBLIC_KEY_DIR not set")
+	}
+	key, err := LoadPrivateKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := LoadPublicKeys(keyDir)
+	if err != nil {
+		return nil, err
+	}
+	a, err := NewAuthenticator(service, key, peers)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.UseTLS(TLSConfigFromEnv()); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// UseTLS secures the connections of the service as cfg says. Without it
+// they are plaintext.
+func (a *Authenticator) UseTLS(cfg TLSConfig) error {
+	server, client, err := transportCredentials(cfg)
+	if err != nil {
+		return fmt.Errorf("TLS config: %w", err)
+	}
+	a.serverCreds, a.clientCreds = server, client
+	return nil
+}
+
+// DialOptions secure a client's connection to the service callee and make
+// it sign every call for that service alone
+func (a *Authenticator) DialOptions(callee string) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(a.clientCreds),
+		grpc.WithPerRPCCredentials(credentialsFunc(func(ctx context.Context, uri ...string) (map[string]string, error) {
+			token, err := a.Sign(ctx, callee)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"authorization": "Bearer " + token}, nil
+		})),
+	}
+}
+
+// ServerOptions secure a server's connections and make it check every call
+// against policy
+func (a *Authenticator) ServerOptions(policy Policy) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.Creds(a.serverCreds),
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := a.authorize(ctx, info.FullMethod, policy)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := a.authorize(ss.Context(), info.FullMethod, policy)
+			if err != nil {
+				return err
+			}
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		}),
+	}
+}
+
+// Sign returns a token for a call to the service audience made with ctx.
+// Only the gateway acts for end users, so only its tokens carry one.
+func (a *Authenticator) Sign(ctx context.Context, audience string) (string, error) {
+	now := a.now()
+	claims := serviceClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   a.service,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+		},
+	}
+	if user, ok := ctx.Value(userKey{}).(*Identity); ok && a.service == ServiceGateway {
+		claims.AccountID = user.AccountID
+		claims.Role = user.Role
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(a.key)
+}
+
+// Verify checks a token and returns the identity it names. The token must
+// be signed with the key of the service it names, for this service. User
+// claims are only taken from the gateway.
+func (a *Authenticator) Verify(token string) (*Identity, error) {
+	var claims serviceClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		key, ok := a.peers[claims.Subject]
+		if !ok {
+			return nil, fmt.Errorf("no key for service %q", claims.Subject)
+		}
+		return key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithAudience(a.service),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(tokenLeeway),
+		jwt.WithTimeFunc(a.now),
+	)
+	if err != nil || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	if claims.Subject != ServiceGateway && (claims.AccountID != "" || claims.Role != "") {
+		return nil, ErrInvalidToken
+	}
+	return &Identity{
+		Service:   claims.Subject,
+		AccountID: claims.AccountID,
+		Role:      claims.Role,
+	}, nil
+}
+
+// Helper: check the caller of method and put its identity in the context
+func (a *Authenticator) authorize(ctx context.Context, method string, policy Policy) (context.Context, error) {
+	if strings.HasPrefix(method, reflectionPrefix) || strings.HasPrefix(method, healthPrefix) {
+		return ctx, nil
+	}
+	rule, ok := policy[method]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "%s is not open to any caller", method)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing service token")
+	}
+	token, _ := strings.CutPrefix(values[0], "Bearer ")
+	id, err := a.Verify(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !rule.allows(id) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s may not be called by %s", method, describe(id))
+	}
+	return context.WithValue(ctx, identityKey{}, id), nil
+}
+
+// Helper: name a caller in errors
+func describe(id *Identity) string {
+	if id.AccountID == "" {
+		return id.Service
+	}
+	return fmt.Sprintf("%s for account %s (%s)", id.Service, id.AccountID, id.Role)
+}
+
+// credentialsFunc adapts a function to credentials.PerRPCCredentials
+type credentialsFunc func(ctx context.Context, uri ...string) (map[string]string, error)
+
+var _ credentials.PerRPCCredentials = credentialsFunc(nil)
+
+func (f credentialsFunc) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return f(ctx, uri...)
+}
+
+// RequireTransportSecurity is false so that services may still talk over
+// plaintext connections in development. The tokens only live for a minute.
+func (f credentialsFunc) RequireTransportSecurity() bool {
+	return false
+}
+
+// serverStream replaces the context of a stream with the authorized one
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
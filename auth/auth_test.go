@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testKeys holds a key pair per service, shared by all tests
+var testKeys = func() map[string]ed25519.PrivateKey {
+	keys := map[string]ed25519.PrivateKey{}
+	for _, service := range []string{ServiceAccount, ServiceCatalog, ServiceOrder, ServiceGateway} {
+		_, key, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			panic(err)
+		}
+		keys[service] = key
+	}
+	return keys
+}()
+
+// testPeers returns the public keys of all services
+func testPeers() map[string]ed25519.PublicKey {
+	peers := map[string]ed25519.PublicKey{}
+	for service, key := range testKeys {
+		peers[service] = key.Public().(ed25519.PublicKey)
+	}
+	return peers
+}
+
+func newTestAuthenticator(t *testing.T, service string) *Authenticator {
+	t.Helper()
+	a, err := NewAuthenticator(service, testKeys[service], testPeers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// testServer answers EmptyCall, and StreamingOutputCall with one message
+type testServer struct {
+	testpb.UnimplementedTestServiceServer
+}
+
+func (testServer) EmptyCall(context.Context, *testpb.Empty) (*testpb.Empty, error) {
+	return &testpb.Empty{}, nil
+}
+
+func (testServer) StreamingOutputCall(_ *testpb.StreamingOutputCallRequest, stream testpb.TestService_StreamingOutputCallServer) error {
+	return stream.Send(&testpb.StreamingOutputCallResponse{})
+}
+
+// serveTest starts an in-process test and health server guarded by policy
+// and returns a connection dialled with the given options
+func serveTest(t *testing.T, server *Authenticator, policy Policy, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer(server.ServerOptions(policy)...)
+	testpb.RegisterTestServiceServer(srv, testServer{})
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestInterceptors(t *testing.T) {
+	ctx := context.Background()
+	server := newTestAuthenticator(t, ServiceCatalog)
+	policy := Policy{
+		testpb.TestService_EmptyCall_FullMethodName:           {Services: []string{ServiceOrder}},
+		testpb.TestService_StreamingOutputCall_FullMethodName: {Services: []string{ServiceGateway}, Roles: []string{RoleAdmin}},
+	}
+	order := testpb.NewTestServiceClient(serveTest(t, server, policy, newTestAuthenticator(t, ServiceOrder).DialOptions(ServiceCatalog)...))
+	gateway := testpb.NewTestServiceClient(serveTest(t, server, policy, newTestAuthenticator(t, ServiceGateway).DialOptions(ServiceCatalog)...))
+	anonymousConn := serveTest(t, server, policy)
+	anonymous := testpb.NewTestServiceClient(anonymousConn)
+
+	if _, err := order.EmptyCall(ctx, &testpb.Empty{}); err != nil {
+		t.Errorf("allowed service: %v", err)
+	}
+	if _, err := gateway.EmptyCall(ctx, &testpb.Empty{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("other service: got %v, want PermissionDenied", err)
+	}
+	if _, err := anonymous.EmptyCall(ctx, &testpb.Empty{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("no token: got %v, want Unauthenticated", err)
+	}
+	if _, err := order.UnaryCall(ctx, &testpb.SimpleRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("method missing from the policy: got %v, want PermissionDenied", err)
+	}
+	if _, err := healthpb.NewHealthClient(anonymousConn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("health check without a token: %v", err)
+	}
+
+	// Stream RPCs check the end user's role
+	stream := func(ctx context.Context) error {
+		stream, err := gateway.StreamingOutputCall(ctx, &testpb.StreamingOutputCallRequest{})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		return err
+	}
+	if err := stream(WithUser(ctx, "alice", RoleUser)); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("stream for a user: got %v, want PermissionDenied", err)
+	}
+	if err := stream(WithUser(ctx, "root", RoleAdmin)); err != nil {
+		t.Errorf("stream for an admin: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	gateway := newTestAuthenticator(t, ServiceGateway)
+	order := newTestAuthenticator(t, ServiceOrder)
+	catalog := newTestAuthenticator(t, ServiceCatalog)
+
+	token, err := gateway.Sign(WithUser(ctx, "alice", RoleAdmin), ServiceCatalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := catalog.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if *id != (Identity{Service: ServiceGateway, AccountID: "alice", Role: RoleAdmin}) {
+		t.Errorf("got %+v", *id)
+	}
+	if _, err := order.Verify(token); err != ErrInvalidToken {
+		t.Errorf("token for another service: got %v, want ErrInvalidToken", err)
+	}
+
+	// Other services sign for themselves only, and never for a user
+	token, err = order.Sign(WithUser(ctx, "alice", RoleAdmin), ServiceCatalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := catalog.Verify(token); err != nil || *id != (Identity{Service: ServiceOrder}) {
+		t.Errorf("order token: got %+v, %v", id, err)
+	}
+	forge := func(claims serviceClaims) string {
+		claims.Audience = jwt.ClaimStrings{ServiceCatalog}
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(tokenTTL))
+		token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(testKeys[ServiceOrder])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	if _, err := catalog.Verify(forge(serviceClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: ServiceGateway}})); err != ErrInvalidToken {
+		t.Errorf("token naming the gateway signed by order: got %v, want ErrInvalidToken", err)
+	}
+	if _, err := catalog.Verify(forge(serviceClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: ServiceOrder}, AccountID: "root", Role: RoleAdmin})); err != ErrInvalidToken {
+		t.Errorf("user claims from order: got %v, want ErrInvalidToken", err)
+	}
+	if _, err := catalog.Verify(forge(serviceClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: "billing"}})); err != ErrInvalidToken {
+		t.Errorf("unknown service: got %v, want ErrInvalidToken", err)
+	}
+
+	catalog.now = func() time.Time { return time.Now().Add(tokenTTL + tokenLeeway + time.Second) }
+	if _, err := catalog.Verify(token); err != ErrInvalidToken {
+		t.Errorf("expired token: got %v, want ErrInvalidToken", err)
+	}
+	if _, err := NewAuthenticator(ServiceOrder, nil, testPeers()); err == nil {
+		t.Error("missing key accepted")
+	}
+}
+
+func TestNewAuthenticatorFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "public"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for service, key := range testKeys {
+		private, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		public, err := x509.MarshalPKIXPublicKey(key.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, service+".pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: private}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "public", service+publicKeySuffix), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GRPC_TLS_MODE", "")
+	t.Setenv("SERVICE_AUTH_KEY_FILE", filepath.Join(dir, ServiceCatalog+".pem"))
+	t.Setenv("SERVICE_AUTH_PUBLIC_KEY_DIR", filepath.Join(dir, "public"))
+
+	catalog, err := NewAuthenticatorFromEnv(ServiceCatalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := newTestAuthenticator(t, ServiceGateway).Sign(context.Background(), ServiceCatalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := catalog.Verify(token); err != nil {
+		t.Errorf("gateway token: %v", err)
+	}
+
+	t.Setenv("SERVICE_AUTH_KEY_FILE", filepath.Join(dir, "public", ServiceCatalog+publicKeySuffix))
+	if _, err := NewAuthenticatorFromEnv(ServiceCatalog); err == nil {
+		t.Error("public key accepted as the private key")
+	}
+}
+
+func TestRequireOwner(t *testing.T) {
+	call := func(accountID, role string) context.Context {
+		return context.WithValue(context.Background(), identityKey{}, &Identity{Service: ServiceGateway, AccountID: accountID, Role: role})
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"owner", call("alice", RoleUser), codes.OK},
+		{"other user", call("bob", RoleUser), codes.PermissionDenied},
+		{"admin", call("root", RoleAdmin), codes.OK},
+		{"no user", call("", ""), codes.PermissionDenied},
+		{"no identity", context.Background(), codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		if err := RequireOwner(tt.ctx, "alice"); status.Code(err) != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// publicKeySuffix ends the name of the public key files of LoadPublicKeys
+const publicKeySuffix = ".pub"
+
+// LoadPrivateKey reads the PKCS #8 PEM Ed25519 key a service signs its
+// tokens with
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 private key", path)
+	}
+	return key, nil
+}
+
+// LoadPublicKeys reads the PKIX PEM Ed25519 keys of the services whose
+// tokens are accepted, one file per service named after it, such as
+// graphql.pub. Other files are ignored.
+func LoadPublicKeys(dir string) (map[string]ed25519.PublicKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]ed25519.PublicKey{}
+	for _, entry := range entries {
+		service, ok := strings.CutSuffix(entry.Name(), publicKeySuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		block, err := readPEM(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an Ed25519 public key", path)
+		}
+		keys[service] = key
+	}
+	return keys, nil
+}
+
+// Helper: read the first PEM block of a file
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+)
+
+// testCA issues certificates for the tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate for name usable by servers and clients, and
+// its key
+func (ca *testCA) issue(t *testing.T, name string) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeCerts writes a certificate issued by ca into dir and returns the
+// config pointing at it. modTime sets the files' modification time, so that
+// rewrites are seen as changes.
+func writeCerts(t *testing.T, dir string, ca *testCA, mode TLSMode, modTime time.Time) TLSConfig {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, "bufnet")
+	cfg := TLSConfig{
+		Mode:     mode,
+		CertFile: filepath.Join(dir, "tls.crt"),
+		KeyFile:  filepath.Join(dir, "tls.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}
+	for path, data := range map[string][]byte{cfg.CertFile: certPEM, cfg.KeyFile: keyPEM, cfg.CAFile: ca.pem} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cfg
+}
+
+func newTLSAuthenticator(t *testing.T, service string, cfg TLSConfig) *Authenticator {
+	t.Helper()
+	a := newTestAuthenticator(t, service)
+	if err := a.UseTLS(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestMutualTLS(t *testing.T) {
+	ctx := context.Background()
+	ca := newTestCA(t)
+	policy := Policy{testpb.TestService_EmptyCall_FullMethodName: {Services: []string{ServiceOrder}}}
+	server := newTLSAuthenticator(t, ServiceCatalog, writeCerts(t, t.TempDir(), ca, TLSMutual, time.Now()))
+	check := func(client *Authenticator) error {
+		_, err := testpb.NewTestServiceClient(serveTest(t, server, policy, client.DialOptions(ServiceCatalog)...)).EmptyCall(ctx, &testpb.Empty{})
+		return err
+	}
+
+	if err := check(newTLSAuthenticator(t, ServiceOrder, writeCerts(t, t.TempDir(), ca, TLSMutual, time.Now()))); err != nil {
+		t.Errorf("client with a certificate: %v", err)
+	}
+
+	noCert := writeCerts(t, t.TempDir(), ca, TLSServer, time.Now())
+	noCert.CertFile, noCert.KeyFile = "", ""
+	if err := check(newTLSAuthenticator(t, ServiceOrder, noCert)); status.Code(err) != codes.Unavailable {
+		t.Errorf("client without a certificate: got %v, want Unavailable", err)
+	}
+	if err := check(newTLSAuthenticator(t, ServiceOrder, writeCerts(t, t.TempDir(), newTestCA(t), TLSMutual, time.Now()))); status.Code(err) != codes.Unavailable {
+		t.Errorf("client of another CA: got %v, want Unavailable", err)
+	}
+	if err := check(newTestAuthenticator(t, ServiceOrder)); status.Code(err) != codes.Unavailable {
+		t.Errorf("plaintext client: got %v, want Unavailable", err)
+	}
+}
+
+func TestCertificateReload(t *testing.T) {
+	defer func(interval time.Duration) { certCheckInterval = interval }(certCheckInterval)
+	certCheckInterval = 0
+
+	ctx := context.Background()
+	oldCA := newTestCA(t)
+	policy := Policy{testpb.TestService_EmptyCall_FullMethodName: {Services: []string{ServiceOrder}}}
+	serverDir, rotatedDir, staleDir := t.TempDir(), t.TempDir(), t.TempDir()
+	start := time.Now().Add(-time.Minute)
+	server := newTLSAuthenticator(t, ServiceCatalog, writeCerts(t, serverDir, oldCA, TLSMutual, start))
+	rotated := newTLSAuthenticator(t, ServiceOrder, writeCerts(t, rotatedDir, oldCA, TLSMutual, start))
+	stale := newTLSAuthenticator(t, ServiceOrder, writeCerts(t, staleDir, oldCA, TLSMutual, start))
+	check := func(client *Authenticator) error {
+		_, err := testpb.NewTestServiceClient(serveTest(t, server, policy, client.DialOptions(ServiceCatalog)...)).EmptyCall(ctx, &testpb.Empty{})
+		return err
+	}
+	if err := check(rotated); err != nil {
+		t.Fatalf("before rotation: %v", err)
+	}
+
+	// Rotate the CA of the server and one client: the other one keeps
+	// trusting the old CA only
+	newCA := newTestCA(t)
+	writeCerts(t, serverDir, newCA, TLSMutual, time.Now())
+	writeCerts(t, rotatedDir, newCA, TLSMutual, time.Now())
+	if err := check(rotated); err != nil {
+		t.Errorf("after rotation: %v", err)
+	}
+	if err := check(stale); status.Code(err) != codes.Unavailable {
+		t.Errorf("client of the old CA: got %v, want Unavailable", err)
+	}
+
+	// A half written key leaves the previous certificate in use
+	if err := os.WriteFile(filepath.Join(serverDir, "tls.key"), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(rotated); err != nil {
+		t.Errorf("after a failed reload: %v", err)
+	}
+}
+
+func TestUseTLS(t *testing.T) {
+	ca := newTestCA(t)
+	cfg := writeCerts(t, t.TempDir(), ca, TLSMutual, time.Now())
+	a := newTestAuthenticator(t, ServiceOrder)
+
+	if err := a.UseTLS(TLSConfig{Mode: "ssl"}); err == nil {
+		t.Error("unknown mode accepted")
+	}
+	noCA := cfg
+	noCA.CAFile = ""
+	if err := a.UseTLS(noCA); err == nil {
+		t.Error("mtls without a CA accepted")
+	}
+	missing := cfg
+	missing.KeyFile = filepath.Join(t.TempDir(), "missing.key")
+	if err := a.UseTLS(missing); err == nil {
+		t.Error("missing key accepted")
+	}
+	if err := a.UseTLS(TLSConfig{Mode: TLSPlaintext}); err != nil {
+		t.Errorf("plaintext: %v", err)
+	}
+}
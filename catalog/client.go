@@ -3,17 +3,24 @@ package catalog
 import (
 	"context"
 	"log"
+	"time"
 
+	"github.com/pawan-sharma-12/go_microservices/auth"
 	"github.com/pawan-sharma-12/go_microservices/catalog/pb"
+	"github.com/pawan-sharma-12/go_microservices/health"
+	"github.com/pawan-sharma-12/go_microservices/money"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 type Client struct {
 	conn *grpc.ClientConn
 	service pb.CatalogServiceClient
 }
-func NewClient(url string) (*Client, error){
-	conn, err := grpc.Dial(url, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewClient connects to the catalog service. authn secures the connection and
+// signs the calls.
+func NewClient(url string, authn *auth.Authenticator) (*Client, error){
+	conn, err := grpc.Dial(url, append(authn.DialOptions(auth.ServiceCatalog), tracing.DialOption())...)
 	if err != nil {
 		return nil, err
 	}
@@ -26,11 +33,17 @@ func NewClient(url string) (*Client, error){
 func (c *Client) Close() {
 	c.conn.Close()
 }
-func (c *Client) PostProduct(ctx context.Context, name string, description string, price float64) (*Product, error) {
+
+// Health reports whether the catalog service is serving
+func (c *Client) Health(ctx context.Context) error {
+	return health.Probe(ctx, c.conn, pb.CatalogService_ServiceDesc.ServiceName)
+}
+func (c *Client) PostProduct(ctx context.Context, name string, description string, price money.Money, stock uint64) (*Product, error) {
 	r , err := c.service.PostProduct(ctx, &pb.PostProductRequest{
 		Name: name,
 		Description: description,
-		Price: price,
+		Price: convertMoneyToProto(price),
+		Stock: stock,
 	})
 	if err != nil {
 		return nil, err
@@ -39,7 +52,9 @@ func (c *Client) PostProduct(ctx context.Context, name string, description strin
 		ID:          r.Product.Id,
 		Name:        r.Product.Name,
 		Description: r.Product.Description,
-		Price:       r.Product.Price,
+		Price:       convertProtoToMoney(r.Product.Price),
+		Stock:       r.Product.Stock,
+		StockUntracked: r.Product.StockUntracked,
 	}, nil
 }
 func (c *Client) GetProduct(ctx context.Context, id string) (*Product, error) {
@@ -53,7 +68,9 @@ func (c *Client) GetProduct(ctx context.Context, id string) (*Product, error) {
 		ID:          r.Product.Id,
 		Name:        r.Product.Name,
 		Description: r.Product.Description,
-		Price:       r.Product.Price,
+		Price:       convertProtoToMoney(r.Product.Price),
+		Stock:       r.Product.Stock,
+		StockUntracked: r.Product.StockUntracked,
 	}, nil
 }
 func (c *Client) GetProducts(ctx context.Context, skip uint64, take uint64, ids []string, query string) ([]Product, error) {
@@ -73,8 +90,133 @@ func (c *Client) GetProducts(ctx context.Context, skip uint64, take uint64, ids
 			ID:          p.Id,
 			Name:        p.Name,
 			Description: p.Description,
-			Price:       p.Price,
+			Price:       convertProtoToMoney(p.Price),
+			Stock:       p.Stock,
+			StockUntracked: p.StockUntracked,
+			Deleted:     p.Deleted,
 		})
 	}
 	return products, nil
-}
\ No newline at end of file
+}
+
+// GetProductsPage fetches the products after the cursor, searching them if
+// query is set. An empty cursor starts at the first page.
+func (c *Client) GetProductsPage(ctx context.Context, query string, after string, first uint64) (*ProductPage, error) {
+	r, err := c.service.GetProducts(ctx, &pb.GetProductsRequest{
+		Query: query,
+		First: first,
+		After: after,
+	})
+	if err != nil {
+		log.Println("client.go GetProductsPage error : ", err)
+		return nil, err
+	}
+	page := &ProductPage{
+		Products:    make([]Product, 0, len(r.Products)),
+		Cursors:     r.Cursors,
+		HasNextPage: r.HasNextPage,
+	}
+	for _, p := range r.Products {
+		page.Products = append(page.Products, Product{
+			ID:          p.Id,
+			Name:        p.Name,
+			Description: p.Description,
+			Price:       convertProtoToMoney(p.Price),
+			Stock:       p.Stock,
+			StockUntracked: p.StockUntracked,
+			Deleted:     p.Deleted,
+		})
+	}
+	return page, nil
+}
+
+// UpdateProduct changes only the fields set in update
+func (c *Client) UpdateProduct(ctx context.Context, id string, update ProductUpdate) (*Product, error) {
+	req := &pb.UpdateProductRequest{
+		Id:         id,
+		UpdateMask: &fieldmaskpb.FieldMask{},
+	}
+	if update.Name != nil {
+		req.Name = *update.Name
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "name")
+	}
+	if update.Description != nil {
+		req.Description = *update.Description
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "description")
+	}
+	if update.Price != nil {
+		req.Price = convertMoneyToProto(*update.Price)
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "price")
+	}
+	if update.Stock != nil {
+		req.Stock = *update.Stock
+		req.UpdateMask.Paths = append(req.UpdateMask.Paths, "stock")
+	}
+
+	r, err := c.service.UpdateProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &Product{
+		ID:          r.Product.Id,
+		Name:        r.Product.Name,
+		Description: r.Product.Description,
+		Price:       convertProtoToMoney(r.Product.Price),
+		Stock:       r.Product.Stock,
+		StockUntracked: r.Product.StockUntracked,
+	}, nil
+}
+
+func (c *Client) DeleteProduct(ctx context.Context, id string) error {
+	_, err := c.service.DeleteProduct(ctx, &pb.DeleteProductRequest{
+		Id: id,
+	})
+	return err
+}
+
+// ReserveStock holds stock for all items or fails without holding any.
+// A zero ttl uses the service default.
+func (c *Client) ReserveStock(ctx context.Context, items []StockItem, ttl time.Duration) (*Reservation, error) {
+	pbItems := make([]*pb.StockItem, 0, len(items))
+	for _, item := range items {
+		pbItems = append(pbItems, &pb.StockItem{
+			ProductId: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+	r, err := c.service.ReserveStock(ctx, &pb.ReserveStockRequest{
+		Items:      pbItems,
+		TtlSeconds: uint32(ttl / time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := make([]StockItem, 0, len(r.Reservation.Items))
+	for _, item := range r.Reservation.Items {
+		reserved = append(reserved, StockItem{
+			ProductID: item.ProductId,
+			Quantity:  item.Quantity,
+		})
+	}
+	return &Reservation{
+		ID:        r.Reservation.Id,
+		Items:     reserved,
+		Status:    ReservationStatus(r.Reservation.Status),
+		ExpiresAt: r.Reservation.ExpiresAt.AsTime(),
+	}, nil
+}
+
+func (c *Client) CommitReservation(ctx context.Context, id string) error {
+	_, err := c.service.CommitReservation(ctx, &pb.CommitReservationRequest{
+		ReservationId: id,
+	})
+	return err
+}
+
+func (c *Client) ReleaseReservation(ctx context.Context, id string) error {
+	_, err := c.service.ReleaseReservation(ctx, &pb.ReleaseReservationRequest{
+		ReservationId: id,
+	})
+	return err
+}
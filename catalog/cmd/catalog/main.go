@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"strconv"
@@ -8,7 +9,11 @@ import (
 
 	"github.com/avast/retry-go"
 	"github.com/joho/godotenv"
+	"github.com/pawan-sharma-12/go_microservices/auth"
 	"github.com/pawan-sharma-12/go_microservices/catalog"
+	"github.com/pawan-sharma-12/go_microservices/graceful"
+	"github.com/pawan-sharma-12/go_microservices/health"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 )
 
 type Config struct {
@@ -25,6 +30,11 @@ func main() {
 	// Load environment-specific .env file
 	_ = godotenv.Load(".env." + env)
 
+	flushSpans, err := tracing.Setup(context.Background(), auth.ServiceCatalog)
+	if err != nil {
+		log.Fatalf("Could not set up tracing: %v", err)
+	}
+
 	cfg := Config{
 		ElasticURL: os.Getenv("CATALOG_ELASTIC_URL"),
 	}
@@ -36,7 +46,7 @@ func main() {
 
 	// Connect to Elasticsearch with retries
 	var r catalog.Repository
-	err := retry.Do(
+	err = retry.Do(
 		func() error {
 			var err error
 			r, err = catalog.NewElasticRepository(cfg.ElasticURL)
@@ -52,7 +62,6 @@ func main() {
 	if err != nil {
 		log.Fatalf("💥 Could not establish Elasticsearch connection after retries: %v", err)
 	}
-	defer r.Close()
 
 	// Determine port
 	portStr := os.Getenv("CATALOG_SERVICE_PORT")
@@ -65,8 +74,28 @@ func main() {
 		log.Fatalf("Invalid port number: %v", err)
 	}
 
+	authn, err := auth.NewAuthenticatorFromEnv(auth.ServiceCatalog)
+	if err != nil {
+		log.Fatalf("Invalid service auth config: %v", err)
+	}
+
+	if err := graceful.TimeoutFromEnv(); err != nil {
+		log.Fatalf("Invalid shutdown timeout: %v", err)
+	}
+	ctx, stop := graceful.SignalContext()
+	defer stop()
+
 	log.Println("Catalog Service Listening at port", portInt)
 
 	s := catalog.NewService(r)
-	log.Fatal(catalog.ListenAndServeGRPC(s, portInt))
+	err = catalog.ListenAndServeGRPC(ctx, s, portInt, authn, health.Checks{"elasticsearch": r.Ping})
+
+	// The server has drained: stop the reservation sweeper, then close
+	// Elasticsearch
+	s.Close()
+	flushSpans()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("👋 Catalog service stopped")
 }
@@ -0,0 +1,388 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/pawan-sharma-12/go_microservices/cursor"
+)
+
+// memoryRepository keeps products and reservations in memory. It is safe for
+// concurrent use and follows the semantics of the Elasticsearch repository:
+// listings keep insertion order and hide deleted products, and search matches
+// any word of the query in the name or description.
+type memoryRepository struct {
+	mu           sync.RWMutex
+	products     map[string]*memoryProduct
+	order        []string
+	reservations map[string]Reservation
+}
+
+type memoryProduct struct {
+	Product
+	deletedAt *time.Time
+	// holds is the stock taken for each reservation not yet committed or
+	// released, as in the holds field of Elasticsearch documents
+	holds map[string]uint64
+}
+
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		products:     make(map[string]*memoryProduct),
+		reservations: make(map[string]Reservation),
+	}
+}
+
+func (r *memoryRepository) Close() {}
+
+func (r *memoryRepository) Ping(ctx context.Context) error { return nil }
+
+func (r *memoryRepository) PutProduct(ctx context.Context, p Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Indexing an existing ID replaces the document, as in Elasticsearch
+	if _, ok := r.products[p.ID]; !ok {
+		r.order = append(r.order, p.ID)
+	}
+	p.Deleted = false
+	r.products[p.ID] = &memoryProduct{Product: p}
+	return nil
+}
+
+func (r *memoryRepository) GetProductByID(ctx context.Context, id string) (*Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.products[id]
+	if !ok || p.deletedAt != nil {
+		return nil, ErrNotFound
+	}
+	product := p.Product
+	return &product, nil
+}
+
+func (r *memoryRepository) ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	products := []Product{}
+	for _, id := range r.order {
+		if p := r.products[id]; p.deletedAt == nil {
+			products = append(products, p.Product)
+		}
+	}
+	return page(products, skip, take), nil
+}
+
+// ListProductsWithIDs returns the products in the order of ids, including
+// deleted ones. Unknown IDs are skipped.
+func (r *memoryRepository) ListProductsWithIDs(ctx context.Context, ids []string) ([]Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	products := []Product{}
+	for _, id := range ids {
+		p, ok := r.products[id]
+		if !ok {
+			continue
+		}
+		product := p.Product
+		product.Deleted = p.deletedAt != nil
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+// SearchProducts ranks products by the number of query words they contain,
+// keeping insertion order between equal matches
+func (r *memoryRepository) SearchProducts(ctx context.Context, query string, skip uint64, take uint64) ([]Product, error) {
+	hits := r.search(query)
+	products := make([]Product, 0, len(hits))
+	for _, h := range hits {
+		products = append(products, h.product)
+	}
+	return page(products, skip, take), nil
+}
+
+// ListProductsPage pages through ListProducts or SearchProducts order. The
+// sort values are the insertion sequence, preceded by the score when
+// searching. There is no point in time, so changes made while paging show up
+// in later pages.
+func (r *memoryRepository) ListProductsPage(ctx context.Context, query string, after *ProductCursor, first uint64) ([]Product, []ProductCursor, error) {
+	var hits []memoryHit
+	if query != "" {
+		hits = r.search(query)
+	} else {
+		r.mu.RLock()
+		for seq, id := range r.order {
+			if p := r.products[id]; p.deletedAt == nil {
+				hits = append(hits, memoryHit{product: p.Product, seq: int64(seq)})
+			}
+		}
+		r.mu.RUnlock()
+	}
+
+	var afterScore, afterSeq int64 = 0, -1
+	if after != nil && len(after.SearchAfter) > 0 {
+		values, ok := sortValues(after.SearchAfter)
+		if !ok || (query == "" && len(values) != 1) || (query != "" && len(values) != 2) {
+			return nil, nil, fmt.Errorf("%w: malformed sort values", cursor.ErrInvalid)
+		}
+		afterSeq = values[len(values)-1]
+		if query != "" {
+			afterScore = values[0]
+		}
+	}
+
+	products := []Product{}
+	cursors := []ProductCursor{}
+	for _, h := range hits {
+		if uint64(len(products)) == first {
+			break
+		}
+		if query == "" && h.seq <= afterSeq {
+			continue
+		}
+		if query != "" && afterSeq >= 0 && (h.score > afterScore || (h.score == afterScore && h.seq <= afterSeq)) {
+			continue
+		}
+		position := ProductCursor{Query: query, SearchAfter: []interface{}{h.seq}}
+		if query != "" {
+			position.SearchAfter = []interface{}{h.score, h.seq}
+		}
+		products = append(products, h.product)
+		cursors = append(cursors, position)
+	}
+	return products, cursors, nil
+}
+
+type memoryHit struct {
+	product Product
+	score   int64
+	seq     int64
+}
+
+// search returns the products matching any word of query, best first
+func (r *memoryRepository) search(query string) []memoryHit {
+	terms := words(query)
+
+	r.mu.RLock()
+	hits := []memoryHit{}
+	for seq, id := range r.order {
+		p := r.products[id]
+		if p.deletedAt != nil {
+			continue
+		}
+		text := map[string]bool{}
+		for _, w := range words(p.Name + " " + p.Description) {
+			text[w] = true
+		}
+		var score int64
+		for _, term := range terms {
+			if text[term] {
+				score++
+			}
+		}
+		if score > 0 {
+			hits = append(hits, memoryHit{product: p.Product, score: score, seq: int64(seq)})
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+	return hits
+}
+
+func (r *memoryRepository) UpdateProduct(ctx context.Context, id string, update ProductUpdate) (*Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok || p.deletedAt != nil {
+		return nil, ErrNotFound
+	}
+	if update.Name != nil {
+		p.Name = *update.Name
+	}
+	if update.Description != nil {
+		p.Description = *update.Description
+	}
+	if update.Price != nil {
+		p.Price = *update.Price
+	}
+	if update.Stock != nil {
+		p.Stock = *update.Stock
+		p.StockUntracked = false
+	}
+	product := p.Product
+	return &product, nil
+}
+
+func (r *memoryRepository) DeleteProduct(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.products[id]
+	if !ok || p.deletedAt != nil {
+		return ErrNotFound
+	}
+	p.deletedAt = &at
+	return nil
+}
+
+// ReserveStock takes stock for every item of the reservation or for none of
+// them. Deleted products have no stock to give, and untracked stock is
+// never short.
+func (r *memoryRepository) ReserveStock(ctx context.Context, res Reservation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.reservations[res.ID]; ok {
+		return fmt.Errorf("reservation %s already exists", res.ID)
+	}
+
+	// Check the totals first, so a failure leaves nothing to roll back
+	needed := map[string]uint64{}
+	for _, item := range res.Items {
+		p, ok := r.products[item.ProductID]
+		if !ok {
+			return fmt.Errorf("%w: product %s", ErrNotFound, item.ProductID)
+		}
+		needed[item.ProductID] += item.Quantity
+		if p.deletedAt != nil || (!p.StockUntracked && p.Stock < needed[item.ProductID]) {
+			return fmt.Errorf("%w: product %s", ErrInsufficientStock, item.ProductID)
+		}
+	}
+	for id, quantity := range needed {
+		p := r.products[id]
+		if p.StockUntracked {
+			// Nothing taken, so nothing to give back if stock is set later
+			quantity = 0
+		}
+		p.Stock -= quantity
+		if p.holds == nil {
+			p.holds = map[string]uint64{}
+		}
+		p.holds[res.ID] = quantity
+	}
+
+	res.Items = append([]StockItem(nil), res.Items...)
+	r.reservations[res.ID] = res
+	return nil
+}
+
+func (r *memoryRepository) GetReservation(ctx context.Context, id string) (*Reservation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res, ok := r.reservations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	res.Items = append([]StockItem(nil), res.Items...)
+	return &res, nil
+}
+
+func (r *memoryRepository) CommitReservation(ctx context.Context, id string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res, ok := r.reservations[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if res.Status != ReservationPending {
+		return ErrReservationNotPending
+	}
+	if !now.Before(res.ExpiresAt) {
+		return ErrReservationExpired
+	}
+	res.Status = ReservationCommitted
+	r.reservations[id] = res
+	for _, item := range res.Items {
+		if p, ok := r.products[item.ProductID]; ok {
+			delete(p.holds, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) ReleaseReservation(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res, ok := r.reservations[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if res.Status != ReservationPending && res.Status != ReservationReleasing {
+		return ErrReservationNotPending
+	}
+	res.Status = ReservationReleased
+	r.reservations[id] = res
+	// Only what is still held goes back, as a release that was interrupted
+	// may have returned some of it already
+	for _, item := range res.Items {
+		if p, ok := r.products[item.ProductID]; ok {
+			p.Stock += p.holds[id]
+			delete(p.holds, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) ListExpiredReservations(ctx context.Context, now time.Time) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := []string{}
+	for id, res := range r.reservations {
+		if (res.Status == ReservationPending && !res.ExpiresAt.After(now)) || res.Status == ReservationReleasing {
+			ids = append(ids, id)
+		}
+		if len(ids) == 100 {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// Helper: apply from/size to an already ordered slice
+func page[T any](items []T, skip uint64, take uint64) []T {
+	if skip >= uint64(len(items)) {
+		return []T{}
+	}
+	items = items[skip:]
+	if take < uint64(len(items)) {
+		items = items[:take]
+	}
+	return items
+}
+
+// Helper: read sort values back from a decoded cursor, where they are
+// json.Number, or from one that never left the process
+func sortValues(values []interface{}) ([]int64, bool) {
+	out := make([]int64, 0, len(values))
+	for _, v := range values {
+		switch n := v.(type) {
+		case int64:
+			out = append(out, n)
+		case float64:
+			out = append(out, int64(n))
+		case json.Number:
+			i, err := n.Int64()
+			if err != nil {
+				return nil, false
+			}
+			out = append(out, i)
+		default:
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// Helper: split text into lower-case words, roughly like the standard analyzer
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	})
+}
@@ -4,22 +4,45 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"math"
+	"time"
 
 	"github.com/olivere/elastic/v7"
+	"github.com/pawan-sharma-12/go_microservices/money"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 )
 
 var (
-	ErrNotFound = errors.New("entity not found")
+	ErrNotFound              = errors.New("entity not found")
+	ErrInsufficientStock     = errors.New("insufficient stock")
+	ErrReservationExpired    = errors.New("reservation expired")
+	ErrReservationNotPending = errors.New("reservation is no longer pending")
+	ErrCursorExpired         = errors.New("cursor expired")
 )
 
+// pitKeepAlive is how long a point in time used for paging stays open after
+// each page
+const pitKeepAlive = "1m"
+
 type Repository interface {
 	Close()
+	// Ping checks that the cluster is healthy enough to serve the indices
+	Ping(ctx context.Context) error
 	PutProduct(ctx context.Context, product Product) error
 	GetProductByID(ctx context.Context, id string) (*Product, error)
 	ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error)
 	ListProductsWithIDs(ctx context.Context, ids []string) ([]Product, error)
 	SearchProducts(ctx context.Context, query string, skip uint64, take uint64) ([]Product, error)
+	ListProductsPage(ctx context.Context, query string, after *ProductCursor, first uint64) ([]Product, []ProductCursor, error)
+	UpdateProduct(ctx context.Context, id string, update ProductUpdate) (*Product, error)
+	DeleteProduct(ctx context.Context, id string, at time.Time) error
+	ReserveStock(ctx context.Context, reservation Reservation) error
+	GetReservation(ctx context.Context, id string) (*Reservation, error)
+	CommitReservation(ctx context.Context, id string, now time.Time) error
+	ReleaseReservation(ctx context.Context, id string) error
+	ListExpiredReservations(ctx context.Context, now time.Time) ([]string, error)
 }
 
 type elasticRepository struct {
@@ -27,13 +50,40 @@ type elasticRepository struct {
 }
 
 type productDocument struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	PriceAmount int64  `json:"price_amount"`
+	Currency    string `json:"currency"`
+	// Stock is the quantity available for new reservations. Documents
+	// indexed before stock was tracked have none, see Product.StockUntracked.
+	Stock *uint64 `json:"stock"`
+	// DeletedAt is set when the product is soft-deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// LegacyPrice is the float price of documents indexed before prices
+	// were stored in minor units
+	LegacyPrice *float64 `json:"price,omitempty"`
+}
+
+func (d productDocument) price() money.Money {
+	if d.Currency == "" && d.LegacyPrice != nil {
+		return money.FromFloat(*d.LegacyPrice, money.DefaultCurrency)
+	}
+	return money.New(d.PriceAmount, d.Currency)
+}
+
+func (d productDocument) stock() uint64 {
+	if d.Stock == nil {
+		return 0
+	}
+	return *d.Stock
 }
 
 func NewElasticRepository(url string) (Repository, error) {
-	client, err := elastic.NewClient(elastic.SetURL(url), elastic.SetSniff(false))
+	client, err := elastic.NewClient(
+		elastic.SetURL(url),
+		elastic.SetSniff(false),
+		elastic.SetHttpClient(tracing.ElasticsearchClient()),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +99,33 @@ func NewElasticRepository(url string) (Repository, error) {
 				"properties": {
 					"name": {"type": "text"},
 					"description": {"type": "text"},
-					"price": {"type": "double"}
+					"price_amount": {"type": "long"},
+					"currency": {"type": "keyword"},
+					"stock": {"type": "long"},
+					"holds": {"type": "object", "enabled": false},
+					"deleted_at": {"type": "date"}
+				}
+			}
+		}`).Do(context.Background())
+		if err != nil {
+			return nil, err
+		}
+	} else if err := migrateLegacyPrices(context.Background(), client); err != nil {
+		return nil, err
+	}
+
+	// Ensure the reservations index exists
+	exists, err = client.IndexExists("catalog_reservations").Do(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		_, err := client.CreateIndex("catalog_reservations").BodyString(`{
+			"mappings": {
+				"properties": {
+					"items": {"type": "object", "enabled": false},
+					"status": {"type": "keyword"},
+					"expires_at": {"type": "date"}
 				}
 			}
 		}`).Do(context.Background())
@@ -61,19 +137,84 @@ func NewElasticRepository(url string) (Repository, error) {
 	return &elasticRepository{client: client}, nil
 }
 
+// migrateLegacyPrices adds the minor-unit price and stock fields to an
+// existing index and rewrites documents that still carry a float price.
+// It is idempotent.
+func migrateLegacyPrices(ctx context.Context, client *elastic.Client) error {
+	_, err := client.PutMapping().Index("catalog").BodyString(`{
+		"properties": {
+			"price_amount": {"type": "long"},
+			"currency": {"type": "keyword"},
+			"stock": {"type": "long"},
+			"holds": {"type": "object", "enabled": false},
+			"deleted_at": {"type": "date"}
+		}
+	}`).Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Legacy prices are in major units of the default currency, scaled the
+	// way money.FromFloat does
+	script := elastic.NewScript(`
+		ctx._source.price_amount = Math.round(ctx._source.price * params.scale);
+		ctx._source.currency = params.currency;
+		ctx._source.remove('price');
+	`).
+		Param("currency", money.DefaultCurrency).
+		Param("scale", math.Pow10(money.Exponent(money.DefaultCurrency)))
+	query := elastic.NewBoolQuery().
+		Must(elastic.NewExistsQuery("price")).
+		MustNot(elastic.NewExistsQuery("currency"))
+
+	res, err := client.UpdateByQuery("catalog").
+		Query(query).
+		Script(script).
+		ProceedOnVersionConflict().
+		Refresh("true").
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	if res.Updated > 0 {
+		log.Printf("Migrated %d products to minor-unit prices", res.Updated)
+	}
+	return nil
+}
+
 func (r *elasticRepository) Close() {
 	r.client.Stop()
 }
 
+// Ping accepts a yellow cluster: single node clusters have no replicas
+func (r *elasticRepository) Ping(ctx context.Context) error {
+	res, err := r.client.ClusterHealth().Index("catalog", "catalog_reservations").Do(ctx)
+	if err != nil {
+		return err
+	}
+	if res.Status == "red" {
+		return fmt.Errorf("cluster health is %s", res.Status)
+	}
+	return nil
+}
+
 func (r *elasticRepository) PutProduct(ctx context.Context, p Product) error {
+	doc := productDocument{
+		Name:        p.Name,
+		Description: p.Description,
+		PriceAmount: p.Price.Amount,
+		Currency:    p.Price.Currency,
+		Stock:       &p.Stock,
+	}
+	if p.StockUntracked {
+		doc.Stock = nil
+	}
 	_, err := r.client.Index().
 		Index("catalog").
 		Id(p.ID).
-		BodyJson(productDocument{
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
-		}).
+		BodyJson(doc).
+		// New products show up in listings as soon as they are created
+		Refresh("wait_for").
 		Do(ctx)
 	return err
 }
@@ -98,19 +239,24 @@ func (r *elasticRepository) GetProductByID(ctx context.Context, id string) (*Pro
 	if err := json.Unmarshal(result.Source, &doc); err != nil {
 		return nil, err
 	}
+	if doc.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
 
 	return &Product{
-		ID:          result.Id,
-		Name:        doc.Name,
-		Description: doc.Description,
-		Price:       doc.Price,
+		ID:             result.Id,
+		Name:           doc.Name,
+		Description:    doc.Description,
+		Price:          doc.price(),
+		Stock:          doc.stock(),
+		StockUntracked: doc.Stock == nil,
 	}, nil
 }
 
 func (r *elasticRepository) ListProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error) {
 	results, err := r.client.Search().
 		Index("catalog").
-		Query(elastic.NewMatchAllQuery()).
+		Query(elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("deleted_at"))).
 		From(int(skip)).
 		Size(int(take)).
 		Do(ctx)
@@ -126,10 +272,12 @@ func (r *elasticRepository) ListProducts(ctx context.Context, skip uint64, take
 			continue
 		}
 		products = append(products, Product{
-			ID:          hit.Id,
-			Name:        doc.Name,
-			Description: doc.Description,
-			Price:       doc.Price,
+			ID:             hit.Id,
+			Name:           doc.Name,
+			Description:    doc.Description,
+			Price:          doc.price(),
+			Stock:          doc.stock(),
+			StockUntracked: doc.Stock == nil,
 		})
 	}
 	return products, nil
@@ -152,10 +300,13 @@ func (r *elasticRepository) ListProductsWithIDs(ctx context.Context, ids []strin
 			var p productDocument
 			if err := json.Unmarshal(doc.Source, &p); err == nil {
 				products = append(products, Product{
-					ID:          doc.Id,
-					Name:        p.Name,
-					Description: p.Description,
-					Price:       p.Price,
+					ID:             doc.Id,
+					Name:           p.Name,
+					Description:    p.Description,
+					Price:          p.price(),
+					Stock:          p.stock(),
+					StockUntracked: p.Stock == nil,
+					Deleted:        p.DeletedAt != nil,
 				})
 			}
 		}
@@ -166,7 +317,9 @@ func (r *elasticRepository) ListProductsWithIDs(ctx context.Context, ids []strin
 func (r *elasticRepository) SearchProducts(ctx context.Context, query string, skip uint64, take uint64) ([]Product, error) {
 	res, err := r.client.Search().
 		Index("catalog").
-		Query(elastic.NewMultiMatchQuery(query, "name", "description")).
+		Query(elastic.NewBoolQuery().
+			Must(elastic.NewMultiMatchQuery(query, "name", "description")).
+			MustNot(elastic.NewExistsQuery("deleted_at"))).
 		From(int(skip)).
 		Size(int(take)).
 		Do(ctx)
@@ -180,13 +333,426 @@ func (r *elasticRepository) SearchProducts(ctx context.Context, query string, sk
 		var p productDocument
 		if err := json.Unmarshal(hit.Source, &p); err == nil {
 			products = append(products, Product{
-				ID:          hit.Id,
-				Name:        p.Name,
-				Description: p.Description,
-				Price:       p.Price,
+				ID:             hit.Id,
+				Name:           p.Name,
+				Description:    p.Description,
+				Price:          p.price(),
+				Stock:          p.stock(),
+				StockUntracked: p.Stock == nil,
 			})
 		}
 	}
 
 	return products, nil
 }
+
+// ListProductsPage returns up to first products after the cursor, matching
+// query if it is set, along with the position after each product. Pages are
+// read from a point in time, so products indexed or deleted while paging do
+// not shift the results.
+func (r *elasticRepository) ListProductsPage(ctx context.Context, query string, after *ProductCursor, first uint64) ([]Product, []ProductCursor, error) {
+	pitID := ""
+	if after != nil {
+		pitID = after.PitID
+		// The sort values of a cursor only mean something in the point in
+		// time they came from, closed once the listing reached its last page
+		if pitID == "" && len(after.SearchAfter) > 0 {
+			return nil, nil, ErrCursorExpired
+		}
+	}
+	if pitID == "" {
+		pit, err := r.client.OpenPointInTime("catalog").KeepAlive(pitKeepAlive).Do(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		pitID = pit.Id
+	}
+
+	q := elastic.NewBoolQuery().MustNot(elastic.NewExistsQuery("deleted_at"))
+	// _shard_doc breaks ties so every product has a unique position
+	sorters := []elastic.Sorter{elastic.NewFieldSort("_shard_doc")}
+	if query != "" {
+		q = q.Must(elastic.NewMultiMatchQuery(query, "name", "description"))
+		sorters = append([]elastic.Sorter{elastic.NewScoreSort()}, sorters...)
+	}
+	search := r.client.Search().
+		PointInTime(elastic.NewPointInTimeWithKeepAlive(pitID, pitKeepAlive)).
+		Query(q).
+		SortBy(sorters...).
+		Size(int(first))
+	if after != nil && len(after.SearchAfter) > 0 {
+		search = search.SearchAfter(after.SearchAfter...)
+	}
+	results, err := search.Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			return nil, nil, ErrCursorExpired
+		}
+		log.Println("Error executing search:", err)
+		return nil, nil, err
+	}
+	if results.PitId != "" {
+		pitID = results.PitId
+	}
+	// Past the last page the point in time is no longer needed: close it
+	// rather than leave it open until it expires. Cursors of the last page
+	// are then expired.
+	if uint64(len(results.Hits.Hits)) < first {
+		if _, err := r.client.ClosePointInTime(pitID).Do(context.WithoutCancel(ctx)); err != nil {
+			log.Println("Error closing point in time:", err)
+		}
+		pitID = ""
+	}
+
+	products := make([]Product, 0, len(results.Hits.Hits))
+	cursors := make([]ProductCursor, 0, len(results.Hits.Hits))
+	for _, hit := range results.Hits.Hits {
+		var doc productDocument
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			return nil, nil, err
+		}
+		products = append(products, Product{
+			ID:             hit.Id,
+			Name:           doc.Name,
+			Description:    doc.Description,
+			Price:          doc.price(),
+			Stock:          doc.stock(),
+			StockUntracked: doc.Stock == nil,
+		})
+		cursors = append(cursors, ProductCursor{
+			Query:       query,
+			PitID:       pitID,
+			SearchAfter: hit.Sort,
+		})
+	}
+	return products, cursors, nil
+}
+
+// UpdateProduct applies a partial update to a product that is not deleted
+func (r *elasticRepository) UpdateProduct(ctx context.Context, id string, update ProductUpdate) (*Product, error) {
+	if _, err := r.GetProductByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if update.Name != nil {
+		fields["name"] = *update.Name
+	}
+	if update.Description != nil {
+		fields["description"] = *update.Description
+	}
+	if update.Price != nil {
+		fields["price_amount"] = update.Price.Amount
+		fields["currency"] = update.Price.Currency
+	}
+	if update.Stock != nil {
+		fields["stock"] = *update.Stock
+	}
+
+	res, err := r.client.Update().
+		Index("catalog").
+		Id(id).
+		Doc(fields).
+		FetchSource(true).
+		Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var doc productDocument
+	if err := json.Unmarshal(res.GetResult.Source, &doc); err != nil {
+		return nil, err
+	}
+	return &Product{
+		ID:             res.Id,
+		Name:           doc.Name,
+		Description:    doc.Description,
+		Price:          doc.price(),
+		Stock:          doc.stock(),
+		StockUntracked: doc.Stock == nil,
+	}, nil
+}
+
+// DeleteProduct marks a product as deleted. The document is kept so orders
+// can still resolve it by ID.
+func (r *elasticRepository) DeleteProduct(ctx context.Context, id string, at time.Time) error {
+	if _, err := r.GetProductByID(ctx, id); err != nil {
+		return err
+	}
+
+	_, err := r.client.Update().
+		Index("catalog").
+		Id(id).
+		Doc(map[string]interface{}{"deleted_at": at}).
+		Refresh("true").
+		Do(ctx)
+	if elastic.IsNotFound(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type reservationDocument struct {
+	Items     []StockItem       `json:"items"`
+	Status    ReservationStatus `json:"status"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// ReserveStock takes stock for every item of the reservation or for none of
+// them. The pending reservation is indexed first, so that whatever happens
+// next the sweeper finds it. Each product then records the quantity it holds
+// for the reservation in the same scripted update that takes the stock,
+// which Elasticsearch applies atomically per document; releasing gives back
+// exactly what the products hold, even after a partial reservation.
+func (r *elasticRepository) ReserveStock(ctx context.Context, res Reservation) error {
+	_, err := r.client.Index().
+		Index("catalog_reservations").
+		Id(res.ID).
+		OpType("create").
+		BodyJson(reservationDocument{
+			Items:     res.Items,
+			Status:    res.Status,
+			ExpiresAt: res.ExpiresAt,
+		}).
+		Refresh("true").
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range res.Items {
+		if err := r.takeStock(ctx, item, res.ID); err != nil {
+			// Left pending, the sweeper gives the stock back once it expires
+			if rbErr := r.ReleaseReservation(context.WithoutCancel(ctx), res.ID); rbErr != nil {
+				log.Println("Error restoring stock after failed reservation:", rbErr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *elasticRepository) GetReservation(ctx context.Context, id string) (*Reservation, error) {
+	res, _, _, err := r.getReservation(ctx, id)
+	return res, err
+}
+
+// CommitReservation marks a pending reservation as committed. The stock it
+// holds stays taken for good.
+func (r *elasticRepository) CommitReservation(ctx context.Context, id string, now time.Time) error {
+	res, seqNo, primaryTerm, err := r.getReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if res.Status != ReservationPending {
+		return ErrReservationNotPending
+	}
+	if !now.Before(res.ExpiresAt) {
+		return ErrReservationExpired
+	}
+	if err := r.setReservationStatus(ctx, id, ReservationCommitted, seqNo, primaryTerm); err != nil {
+		return err
+	}
+	for _, item := range res.Items {
+		if err := r.dropHold(ctx, item.ProductID, id); err != nil {
+			log.Printf("Error dropping hold of committed reservation %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// ReleaseReservation returns the stock of a pending reservation and marks it
+// released. The reservation is first claimed as releasing, conditionally on
+// its version, so it cannot be committed meanwhile. It is marked released
+// only once every product has its stock back; until then it stays
+// releasing and the sweeper retries.
+func (r *elasticRepository) ReleaseReservation(ctx context.Context, id string) error {
+	res, seqNo, primaryTerm, err := r.getReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	switch res.Status {
+	case ReservationPending:
+		if err := r.setReservationStatus(ctx, id, ReservationReleasing, seqNo, primaryTerm); err != nil {
+			return err
+		}
+		// The claim holds from here: finish even if the caller goes away
+		ctx = context.WithoutCancel(ctx)
+		if res, seqNo, primaryTerm, err = r.getReservation(ctx, id); err != nil {
+			return err
+		}
+	case ReservationReleasing:
+	default:
+		return ErrReservationNotPending
+	}
+
+	var errs []error
+	for _, item := range res.Items {
+		if err := r.returnStock(ctx, item.ProductID, id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return r.setReservationStatus(ctx, id, ReservationReleased, seqNo, primaryTerm)
+}
+
+// ListExpiredReservations returns pending reservations past their expiry and
+// releases that did not finish
+func (r *elasticRepository) ListExpiredReservations(ctx context.Context, now time.Time) ([]string, error) {
+	query := elastic.NewBoolQuery().
+		Should(
+			elastic.NewBoolQuery().
+				Filter(elastic.NewTermQuery("status", ReservationPending)).
+				Filter(elastic.NewRangeQuery("expires_at").Lte(now)),
+			elastic.NewTermQuery("status", ReservationReleasing),
+		).
+		MinimumNumberShouldMatch(1)
+	results, err := r.client.Search().
+		Index("catalog_reservations").
+		Query(query).
+		Size(100).
+		FetchSource(false).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(results.Hits.Hits))
+	for _, hit := range results.Hits.Hits {
+		ids = append(ids, hit.Id)
+	}
+	return ids, nil
+}
+
+func (r *elasticRepository) getReservation(ctx context.Context, id string) (*Reservation, int64, int64, error) {
+	result, err := r.client.Get().
+		Index("catalog_reservations").
+		Id(id).
+		Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			return nil, 0, 0, ErrNotFound
+		}
+		return nil, 0, 0, err
+	}
+	if !result.Found {
+		return nil, 0, 0, ErrNotFound
+	}
+
+	var doc reservationDocument
+	if err := json.Unmarshal(result.Source, &doc); err != nil {
+		return nil, 0, 0, err
+	}
+	return &Reservation{
+		ID:        result.Id,
+		Items:     doc.Items,
+		Status:    doc.Status,
+		ExpiresAt: doc.ExpiresAt,
+	}, *result.SeqNo, *result.PrimaryTerm, nil
+}
+
+func (r *elasticRepository) setReservationStatus(ctx context.Context, id string, status ReservationStatus, seqNo int64, primaryTerm int64) error {
+	_, err := r.client.Update().
+		Index("catalog_reservations").
+		Id(id).
+		Doc(map[string]interface{}{"status": status}).
+		IfSeqNo(seqNo).
+		IfPrimaryTerm(primaryTerm).
+		Refresh("true").
+		Do(ctx)
+	if elastic.IsConflict(err) {
+		return ErrReservationNotPending
+	}
+	return err
+}
+
+// takeStock takes the quantity of an item from its product and records it
+// as held for the reservation. Taking it again is a no-op.
+func (r *elasticRepository) takeStock(ctx context.Context, item StockItem, reservationID string) error {
+	script := elastic.NewScript(`
+		if (ctx._source.holds == null) {
+			ctx._source.holds = [:];
+		}
+		if (!ctx._source.holds.containsKey(params.reservation)) {
+			boolean tracked = ctx._source.stock != null;
+			if (ctx._source.deleted_at != null || (tracked && ctx._source.stock < params.quantity)) {
+				ctx.op = 'noop';
+			} else {
+				// Untracked stock holds nothing, so setting the stock later
+				// does not give back what was never taken
+				long quantity = tracked ? params.quantity : 0;
+				if (tracked) {
+					ctx._source.stock -= quantity;
+				}
+				ctx._source.holds[params.reservation] = quantity;
+			}
+		}
+	`).
+		Param("reservation", reservationID).
+		Param("quantity", item.Quantity)
+
+	res, err := r.client.Update().
+		Index("catalog").
+		Id(item.ProductID).
+		Script(script).
+		RetryOnConflict(3).
+		Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			return fmt.Errorf("%w: product %s", ErrNotFound, item.ProductID)
+		}
+		return err
+	}
+	if res.Result == "noop" {
+		return fmt.Errorf("%w: product %s", ErrInsufficientStock, item.ProductID)
+	}
+	return nil
+}
+
+// returnStock gives a product back the stock it holds for a reservation.
+// Products holding nothing for it are left alone, so returning twice is
+// safe.
+func (r *elasticRepository) returnStock(ctx context.Context, productID string, reservationID string) error {
+	return r.updateHold(ctx, productID, elastic.NewScript(`
+		if (ctx._source.holds != null && ctx._source.holds.containsKey(params.reservation)) {
+			long quantity = ctx._source.holds.remove(params.reservation);
+			if (ctx._source.stock != null) {
+				ctx._source.stock += quantity;
+			}
+		} else {
+			ctx.op = 'noop';
+		}
+	`).Param("reservation", reservationID))
+}
+
+// dropHold forgets what a product holds for a committed reservation, keeping
+// the stock taken
+func (r *elasticRepository) dropHold(ctx context.Context, productID string, reservationID string) error {
+	return r.updateHold(ctx, productID, elastic.NewScript(`
+		if (ctx._source.holds != null && ctx._source.holds.containsKey(params.reservation)) {
+			ctx._source.holds.remove(params.reservation);
+		} else {
+			ctx.op = 'noop';
+		}
+	`).Param("reservation", reservationID))
+}
+
+// Helper: run a script changing the holds of a product. A product that is
+// gone holds nothing.
+func (r *elasticRepository) updateHold(ctx context.Context, productID string, script *elastic.Script) error {
+	_, err := r.client.Update().
+		Index("catalog").
+		Id(productID).
+		Script(script).
+		RetryOnConflict(3).
+		Do(ctx)
+	if elastic.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
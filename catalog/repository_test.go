@@ -0,0 +1,531 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/money"
+	"github.com/segmentio/ksuid"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewMemoryRepository()
+	}, func(t *testing.T, repo Repository, id string) {
+		r := repo.(*memoryRepository)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		res := r.reservations[id]
+		res.Status = ReservationReleasing
+		r.reservations[id] = res
+	})
+}
+
+// TestElasticRepository runs the conformance suite against a real cluster.
+// Point CATALOG_TEST_ELASTICSEARCH_URL at a disposable instance.
+func TestElasticRepository(t *testing.T) {
+	url := os.Getenv("CATALOG_TEST_ELASTICSEARCH_URL")
+	if url == "" {
+		t.Skip("CATALOG_TEST_ELASTICSEARCH_URL not set")
+	}
+	testRepository(t, func(t *testing.T) Repository {
+		repo, err := NewElasticRepository(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	}, func(t *testing.T, repo Repository, id string) {
+		r := repo.(*elasticRepository)
+		ctx := context.Background()
+		_, seqNo, primaryTerm, err := r.getReservation(ctx, id)
+		if err == nil {
+			err = r.setReservationStatus(ctx, id, ReservationReleasing, seqNo, primaryTerm)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// The point in time of a listing is closed at its last page, and the
+	// cursors of that page with it
+	t.Run("LastPageCursorExpires", func(t *testing.T) {
+		repo, err := NewElasticRepository(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer repo.Close()
+		ctx := context.Background()
+		p := Product{ID: ksuid.New().String(), Name: "last page", Price: money.New(100, "USD")}
+		if err := repo.PutProduct(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		_, cursors, err := repo.ListProductsPage(ctx, "", nil, 10000)
+		if err != nil || len(cursors) == 0 {
+			t.Fatalf("ListProductsPage: %v, %d cursors", err, len(cursors))
+		}
+		if _, _, err := repo.ListProductsPage(ctx, "", &cursors[0], 10); !errors.Is(err, ErrCursorExpired) {
+			t.Errorf("resuming from the last page: got %v, want ErrCursorExpired", err)
+		}
+	})
+}
+
+// testRepository checks the behaviour every Repository implementation must
+// share. Tests only look at products they created, so the backend may
+// already hold other data. markReleasing leaves a reservation as a release
+// that was interrupted after claiming it.
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository, markReleasing func(t *testing.T, repo Repository, id string)) {
+	ctx := context.Background()
+	newProduct := func(t *testing.T, repo Repository, name string, stock uint64) Product {
+		t.Helper()
+		p := Product{
+			ID:          ksuid.New().String(),
+			Name:        name,
+			Description: "a test product",
+			Price:       money.New(1250, "USD"),
+			Stock:       stock,
+		}
+		if err := repo.PutProduct(ctx, p); err != nil {
+			t.Fatalf("PutProduct: %v", err)
+		}
+		return p
+	}
+	newReservation := func(ttl time.Duration, items ...StockItem) Reservation {
+		return Reservation{
+			ID:        ksuid.New().String(),
+			Items:     items,
+			Status:    ReservationPending,
+			ExpiresAt: time.Now().UTC().Add(ttl),
+		}
+	}
+	productOf := func(t *testing.T, repo Repository, id string) Product {
+		t.Helper()
+		products, err := repo.ListProductsWithIDs(ctx, []string{id})
+		if err != nil || len(products) != 1 {
+			t.Fatalf("ListProductsWithIDs: %v, %d products", err, len(products))
+		}
+		return products[0]
+	}
+	stockOf := func(t *testing.T, repo Repository, id string) uint64 {
+		t.Helper()
+		return productOf(t, repo, id).Stock
+	}
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		p := newProduct(t, repo, "lamp", 3)
+
+		got, err := repo.GetProductByID(ctx, p.ID)
+		if err != nil {
+			t.Fatalf("GetProductByID: %v", err)
+		}
+		if *got != p {
+			t.Errorf("got %+v, want %+v", *got, p)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		id := ksuid.New().String()
+		name := "x"
+
+		if _, err := repo.GetProductByID(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetProductByID: got %v, want ErrNotFound", err)
+		}
+		if _, err := repo.UpdateProduct(ctx, id, ProductUpdate{Name: &name}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateProduct: got %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteProduct(ctx, id, time.Now()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteProduct: got %v, want ErrNotFound", err)
+		}
+		if _, err := repo.GetReservation(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetReservation: got %v, want ErrNotFound", err)
+		}
+		products, err := repo.ListProductsWithIDs(ctx, []string{id})
+		if err != nil || len(products) != 0 {
+			t.Errorf("ListProductsWithIDs: got %v, %d products", err, len(products))
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		p := newProduct(t, repo, "lamp", 3)
+		name := "desk lamp"
+		price := money.New(999, "EUR")
+
+		got, err := repo.UpdateProduct(ctx, p.ID, ProductUpdate{Name: &name, Price: &price})
+		if err != nil {
+			t.Fatalf("UpdateProduct: %v", err)
+		}
+		want := p
+		want.Name = name
+		want.Price = price
+		if *got != want {
+			t.Errorf("got %+v, want %+v", *got, want)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		p := newProduct(t, repo, "lamp", 3)
+
+		if err := repo.DeleteProduct(ctx, p.ID, time.Now().UTC()); err != nil {
+			t.Fatalf("DeleteProduct: %v", err)
+		}
+		if _, err := repo.GetProductByID(ctx, p.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetProductByID: got %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteProduct(ctx, p.ID, time.Now().UTC()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("second DeleteProduct: got %v, want ErrNotFound", err)
+		}
+		products, err := repo.ListProductsWithIDs(ctx, []string{p.ID})
+		if err != nil || len(products) != 1 || !products[0].Deleted {
+			t.Errorf("ListProductsWithIDs: got %v, %+v", err, products)
+		}
+		all, err := repo.ListProducts(ctx, 0, 1000)
+		if err != nil {
+			t.Fatalf("ListProducts: %v", err)
+		}
+		for _, listed := range all {
+			if listed.ID == p.ID {
+				t.Errorf("deleted product %s is listed", p.ID)
+			}
+		}
+	})
+
+	t.Run("ListPagination", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		for i := 0; i < 3; i++ {
+			newProduct(t, repo, "list", 1)
+		}
+
+		all, err := repo.ListProducts(ctx, 0, 1000)
+		if err != nil {
+			t.Fatalf("ListProducts: %v", err)
+		}
+		if len(all) < 3 {
+			t.Fatalf("got %d products, want at least 3", len(all))
+		}
+		page, err := repo.ListProducts(ctx, 1, 2)
+		if err != nil {
+			t.Fatalf("ListProducts: %v", err)
+		}
+		if len(page) != 2 {
+			t.Fatalf("got %d products, want 2", len(page))
+		}
+		for i, p := range page {
+			if p.ID != all[i+1].ID {
+				t.Errorf("page[%d] = %s, want %s", i, p.ID, all[i+1].ID)
+			}
+		}
+	})
+
+	t.Run("ListWithIDsKeepsOrder", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		a := newProduct(t, repo, "first", 1)
+		b := newProduct(t, repo, "second", 1)
+
+		products, err := repo.ListProductsWithIDs(ctx, []string{b.ID, ksuid.New().String(), a.ID})
+		if err != nil {
+			t.Fatalf("ListProductsWithIDs: %v", err)
+		}
+		if len(products) != 2 || products[0].ID != b.ID || products[1].ID != a.ID {
+			t.Errorf("got %+v, want %s then %s", products, b.ID, a.ID)
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		word := "w" + strings.ToLower(ksuid.New().String())
+		p := newProduct(t, repo, "red "+word, 1)
+		deleted := newProduct(t, repo, "blue "+word, 1)
+		if err := repo.DeleteProduct(ctx, deleted.ID, time.Now().UTC()); err != nil {
+			t.Fatalf("DeleteProduct: %v", err)
+		}
+
+		found, err := repo.SearchProducts(ctx, strings.ToUpper(word), 0, 10)
+		if err != nil {
+			t.Fatalf("SearchProducts: %v", err)
+		}
+		if len(found) != 1 || found[0].ID != p.ID {
+			t.Errorf("got %+v, want only %s", found, p.ID)
+		}
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		word := "w" + strings.ToLower(ksuid.New().String())
+		want := map[string]bool{}
+		for i := 0; i < 3; i++ {
+			want[newProduct(t, repo, "page "+word, 1).ID] = true
+		}
+		deleted := newProduct(t, repo, "page "+word, 1)
+		if err := repo.DeleteProduct(ctx, deleted.ID, time.Now().UTC()); err != nil {
+			t.Fatalf("DeleteProduct: %v", err)
+		}
+
+		for _, query := range []string{"", word} {
+			seen := map[string]bool{}
+			var after *ProductCursor
+			for pages := 0; ; pages++ {
+				if pages > 10000 {
+					t.Fatalf("query %q: paging does not end", query)
+				}
+				products, cursors, err := repo.ListProductsPage(ctx, query, after, 2)
+				if err != nil {
+					t.Fatalf("query %q: ListProductsPage: %v", query, err)
+				}
+				if len(cursors) != len(products) || len(products) > 2 {
+					t.Fatalf("query %q: got %d products and %d cursors", query, len(products), len(cursors))
+				}
+				for _, p := range products {
+					if seen[p.ID] {
+						t.Fatalf("query %q: product %s returned twice", query, p.ID)
+					}
+					seen[p.ID] = true
+				}
+				if len(products) < 2 {
+					break
+				}
+				after = &cursors[len(cursors)-1]
+			}
+			for id := range want {
+				if !seen[id] {
+					t.Errorf("query %q: product %s never returned", query, id)
+				}
+			}
+			if seen[deleted.ID] {
+				t.Errorf("query %q: deleted product returned", query)
+			}
+			if query != "" && len(seen) != len(want) {
+				t.Errorf("query %q: got %d products, want %d", query, len(seen), len(want))
+			}
+		}
+	})
+
+	t.Run("ReserveCommit", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		p := newProduct(t, repo, "lamp", 5)
+		res := newReservation(time.Minute, StockItem{ProductID: p.ID, Quantity: 2})
+
+		if err := repo.ReserveStock(ctx, res); err != nil {
+			t.Fatalf("ReserveStock: %v", err)
+		}
+		if got := stockOf(t, repo, p.ID); got != 3 {
+			t.Errorf("stock after reserve = %d, want 3", got)
+		}
+		if err := repo.CommitReservation(ctx, res.ID, time.Now().UTC()); err != nil {
+			t.Fatalf("CommitReservation: %v", err)
+		}
+		got, err := repo.GetReservation(ctx, res.ID)
+		if err != nil || got.Status != ReservationCommitted {
+			t.Errorf("GetReservation: got %v, %+v", err, got)
+		}
+		if err := repo.ReleaseReservation(ctx, res.ID); !errors.Is(err, ErrReservationNotPending) {
+			t.Errorf("ReleaseReservation: got %v, want ErrReservationNotPending", err)
+		}
+		if got := stockOf(t, repo, p.ID); got != 3 {
+			t.Errorf("stock after commit = %d, want 3", got)
+		}
+	})
+
+	t.Run("ReserveRelease", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		p := newProduct(t, repo, "lamp", 5)
+		res := newReservation(time.Minute, StockItem{ProductID: p.ID, Quantity: 5})
+
+		if err := repo.ReserveStock(ctx, res); err != nil {
+			t.Fatalf("ReserveStock: %v", err)
+		}
+		if err := repo.ReleaseReservation(ctx, res.ID); err != nil {
+			t.Fatalf("ReleaseReservation: %v", err)
+		}
+		if got := stockOf(t, repo, p.ID); got != 5 {
+			t.Errorf("stock after release = %d, want 5", got)
+		}
+		if err := repo.ReleaseReservation(ctx, res.ID); !errors.Is(err, ErrReservationNotPending) {
+			t.Errorf("second ReleaseReservation: got %v, want ErrReservationNotPending", err)
+		}
+	})
+
+	t.Run("ReserveAllOrNothing", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		a := newProduct(t, repo, "plenty", 5)
+		b := newProduct(t, repo, "scarce", 1)
+		res := newReservation(time.Minute,
+			StockItem{ProductID: a.ID, Quantity: 2},
+			StockItem{ProductID: b.ID, Quantity: 2},
+		)
+
+		if err := repo.ReserveStock(ctx, res); !errors.Is(err, ErrInsufficientStock) {
+			t.Fatalf("ReserveStock: got %v, want ErrInsufficientStock", err)
+		}
+		if got := stockOf(t, repo, a.ID); got != 5 {
+			t.Errorf("stock of %s = %d, want 5", a.ID, got)
+		}
+		if got := stockOf(t, repo, b.ID); got != 1 {
+			t.Errorf("stock of %s = %d, want 1", b.ID, got)
+		}
+
+		missing := newReservation(time.Minute, StockItem{ProductID: ksuid.New().String(), Quantity: 1})
+		if err := repo.ReserveStock(ctx, missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("ReserveStock of unknown product: got %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ReserveDeleted", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		p := newProduct(t, repo, "lamp", 5)
+		if err := repo.DeleteProduct(ctx, p.ID, time.Now().UTC()); err != nil {
+			t.Fatalf("DeleteProduct: %v", err)
+		}
+
+		res := newReservation(time.Minute, StockItem{ProductID: p.ID, Quantity: 1})
+		if err := repo.ReserveStock(ctx, res); !errors.Is(err, ErrInsufficientStock) {
+			t.Errorf("got %v, want ErrInsufficientStock", err)
+		}
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		p := newProduct(t, repo, "lamp", 5)
+		res := newReservation(time.Minute, StockItem{ProductID: p.ID, Quantity: 1})
+		if err := repo.ReserveStock(ctx, res); err != nil {
+			t.Fatalf("ReserveStock: %v", err)
+		}
+
+		later := res.ExpiresAt.Add(time.Second)
+		if err := repo.CommitReservation(ctx, res.ID, later); !errors.Is(err, ErrReservationExpired) {
+			t.Errorf("CommitReservation: got %v, want ErrReservationExpired", err)
+		}
+
+		ids, err := repo.ListExpiredReservations(ctx, time.Now().UTC())
+		if err != nil {
+			t.Fatalf("ListExpiredReservations: %v", err)
+		}
+		for _, id := range ids {
+			if id == res.ID {
+				t.Errorf("reservation %s listed before it expired", res.ID)
+			}
+		}
+		ids, err = repo.ListExpiredReservations(ctx, later)
+		if err != nil {
+			t.Fatalf("ListExpiredReservations: %v", err)
+		}
+		found := false
+		for _, id := range ids {
+			found = found || id == res.ID
+		}
+		if !found {
+			t.Errorf("expired reservation %s not listed", res.ID)
+		}
+	})
+	t.Run("UntrackedStock", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		p := Product{
+			ID:             ksuid.New().String(),
+			Name:           "legacy lamp",
+			Description:    "a product from before stock tracking",
+			Price:          money.New(1250, "USD"),
+			StockUntracked: true,
+		}
+		if err := repo.PutProduct(ctx, p); err != nil {
+			t.Fatalf("PutProduct: %v", err)
+		}
+		if got := productOf(t, repo, p.ID); got.Stock != 0 || !got.StockUntracked {
+			t.Fatalf("got stock %d, untracked %v, want 0 and untracked", got.Stock, got.StockUntracked)
+		}
+
+		committed := newReservation(time.Minute, StockItem{ProductID: p.ID, Quantity: 1000})
+		if err := repo.ReserveStock(ctx, committed); err != nil {
+			t.Fatalf("ReserveStock: %v", err)
+		}
+		if err := repo.CommitReservation(ctx, committed.ID, time.Now().UTC()); err != nil {
+			t.Fatalf("CommitReservation: %v", err)
+		}
+		released := newReservation(time.Minute, StockItem{ProductID: p.ID, Quantity: 3})
+		if err := repo.ReserveStock(ctx, released); err != nil {
+			t.Fatalf("ReserveStock: %v", err)
+		}
+		if got := productOf(t, repo, p.ID); got.Stock != 0 || !got.StockUntracked {
+			t.Errorf("after reserving: got stock %d, untracked %v, want 0 and untracked", got.Stock, got.StockUntracked)
+		}
+
+		// Setting the stock tracks it; the earlier reservation gives back
+		// nothing, as it took nothing
+		stock := uint64(4)
+		if _, err := repo.UpdateProduct(ctx, p.ID, ProductUpdate{Stock: &stock}); err != nil {
+			t.Fatalf("UpdateProduct: %v", err)
+		}
+		if err := repo.ReleaseReservation(ctx, released.ID); err != nil {
+			t.Fatalf("ReleaseReservation: %v", err)
+		}
+		if got := productOf(t, repo, p.ID); got.Stock != 4 || got.StockUntracked {
+			t.Errorf("after setting stock: got stock %d, untracked %v, want 4 and tracked", got.Stock, got.StockUntracked)
+		}
+		if err := repo.ReserveStock(ctx, newReservation(time.Minute, StockItem{ProductID: p.ID, Quantity: 5})); !errors.Is(err, ErrInsufficientStock) {
+			t.Errorf("ReserveStock over the set stock: got %v, want ErrInsufficientStock", err)
+		}
+	})
+
+	t.Run("InterruptedRelease", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		p := newProduct(t, repo, "lamp", 5)
+		res := newReservation(time.Hour, StockItem{ProductID: p.ID, Quantity: 2})
+		if err := repo.ReserveStock(ctx, res); err != nil {
+			t.Fatalf("ReserveStock: %v", err)
+		}
+		markReleasing(t, repo, res.ID)
+
+		// Listed for the sweeper to finish, though it has not expired
+		ids, err := repo.ListExpiredReservations(ctx, time.Now().UTC())
+		if err != nil {
+			t.Fatalf("ListExpiredReservations: %v", err)
+		}
+		found := false
+		for _, id := range ids {
+			found = found || id == res.ID
+		}
+		if !found {
+			t.Errorf("releasing reservation %s not listed", res.ID)
+		}
+		if err := repo.CommitReservation(ctx, res.ID, time.Now().UTC()); !errors.Is(err, ErrReservationNotPending) {
+			t.Errorf("CommitReservation: got %v, want ErrReservationNotPending", err)
+		}
+
+		if err := repo.ReleaseReservation(ctx, res.ID); err != nil {
+			t.Fatalf("ReleaseReservation: %v", err)
+		}
+		if got := stockOf(t, repo, p.ID); got != 5 {
+			t.Errorf("stock after release = %d, want 5", got)
+		}
+		got, err := repo.GetReservation(ctx, res.ID)
+		if err != nil || got.Status != ReservationReleased {
+			t.Errorf("GetReservation: got %v, %+v", err, got)
+		}
+		if err := repo.ReleaseReservation(ctx, res.ID); !errors.Is(err, ErrReservationNotPending) {
+			t.Errorf("second ReleaseReservation: got %v, want ErrReservationNotPending", err)
+		}
+		if got := stockOf(t, repo, p.ID); got != 5 {
+			t.Errorf("stock after second release = %d, want 5", got)
+		}
+	})
+}
@@ -2,48 +2,74 @@ package catalog
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"time"
 
+	"github.com/olivere/elastic/v7"
+	"github.com/pawan-sharma-12/go_microservices/auth"
 	"github.com/pawan-sharma-12/go_microservices/catalog/pb"
+	"github.com/pawan-sharma-12/go_microservices/cursor"
+	"github.com/pawan-sharma-12/go_microservices/graceful"
+	"github.com/pawan-sharma-12/go_microservices/health"
+	"github.com/pawan-sharma-12/go_microservices/money"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 type grpcServer struct {
 	service Service
 	pb.UnimplementedCatalogServiceServer
 
 }
-func ListenAndServeGRPC(service Service, port int) error {
+// accessPolicy says which callers may use each RPC. Only admins change the
+// catalog, and only the order service handles stock reservations.
+var accessPolicy = auth.Policy{
+	pb.CatalogService_PostProduct_FullMethodName:        {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleAdmin}},
+	pb.CatalogService_GetProduct_FullMethodName:         {Services: []string{auth.ServiceGateway, auth.ServiceOrder}},
+	pb.CatalogService_GetProducts_FullMethodName:        {Services: []string{auth.ServiceGateway, auth.ServiceOrder}},
+	pb.CatalogService_UpdateProduct_FullMethodName:      {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleAdmin}},
+	pb.CatalogService_DeleteProduct_FullMethodName:      {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleAdmin}},
+	pb.CatalogService_ReserveStock_FullMethodName:       {Services: []string{auth.ServiceOrder}},
+	pb.CatalogService_CommitReservation_FullMethodName:  {Services: []string{auth.ServiceOrder}},
+	pb.CatalogService_ReleaseReservation_FullMethodName: {Services: []string{auth.ServiceOrder}},
+}
+
+// ListenAndServeGRPC serves the catalog service until ctx is done, then drains
+// it. Callers are checked with authn against accessPolicy. The health
+// service reports it serving while checks pass.
+func ListenAndServeGRPC(ctx context.Context, service Service, port int, authn *auth.Authenticator, checks health.Checks) error {
 	// Implementation for starting gRPC server goes here
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return err
 	}
-	grpcSrv := grpc.NewServer()
+	grpcSrv := grpc.NewServer(append(authn.ServerOptions(accessPolicy), tracing.ServerOption())...)
 	pb.RegisterCatalogServiceServer(grpcSrv,  &grpcServer{
 		service: service,
 		UnimplementedCatalogServiceServer : pb.UnimplementedCatalogServiceServer{},
 		
 	} )
+	healthSrv := health.NewServer(ctx, pb.CatalogService_ServiceDesc.ServiceName, checks)
+	defer healthSrv.Stop()
+	healthSrv.Register(grpcSrv)
 	reflection.Register(grpcSrv)
-	return grpcSrv.Serve(lis)
+	return graceful.ServeGRPC(ctx, grpcSrv, lis)
 }
 
 func (s * grpcServer) PostProduct( ctx context.Context, req *pb.PostProductRequest)(*pb.PostProductResponse, error){
-	product, err := s.service.PostProduct(ctx, req.Name, req.Description, req.Price)
+	product, err := s.service.PostProduct(ctx, req.Name, req.Description, convertProtoToMoney(req.Price), req.Stock)
 	if err != nil {
 		log.Println("Error posting product:", err)
-		return nil, err
+		return nil, productError("", err)
 	}
 	return &pb.PostProductResponse{
-		Product : &pb.Product{
-			Id:          product.ID,
-			Name:        product.Name,
-			Description: product.Description,
-			Price:       product.Price,
-		},
+		Product : convertProductToProto(product),
 	}, nil
 }
 
@@ -51,20 +77,18 @@ func (s * grpcServer) GetProduct( ctx context.Context, req *pb.GetProductRequest
 	product, err := s.service.GetProduct(ctx, req.Id)
 	if err != nil {
 		log.Println("Error getting product:", err)
-		return nil, err
+		return nil, productError(req.Id, err)
 	}
 	return &pb.GetProductResponse{
-		Product : &pb.Product{
-			Id:          product.ID,
-			Name:        product.Name,
-			Description: product.Description,
-			Price:       product.Price,
-		},
+		Product : convertProductToProto(product),
 	}, nil
 }
 func (s * grpcServer) GetProducts( ctx context.Context, r *pb.GetProductsRequest)(*pb.GetProductsResponse, error){	
 	var res []Product 
 	var err error
+	if len(r.Ids) == 0 && (r.First > 0 || r.After != "") {
+		return s.getProductsPage(ctx, r)
+	}
 	if r.Query != ""{
 		res, err = s.service.SearchProducts(ctx, r.Query, r.Skip, r.Take)
 	}else if len(r.Ids) > 0{
@@ -74,18 +98,185 @@ func (s * grpcServer) GetProducts( ctx context.Context, r *pb.GetProductsRequest
 	}
 	if err != nil {
 		log.Println("Error getting products:", err)
-		return nil, err
+		return nil, productError("", err)
 	}
 	var pbProducts []*pb.Product
 	for _, product := range res {
-		pbProducts = append(pbProducts, &pb.Product{
-			Id:          product.ID,
-			Name:        product.Name,
-			Description: product.Description,
-			Price:       product.Price,
-		})
+		pbProducts = append(pbProducts, convertProductToProto(&product))
 	}
 	return &pb.GetProductsResponse{
 		Products: pbProducts,
 	}, nil
 }
+
+// Helper: cursor-paginated variant of GetProducts
+func (s *grpcServer) getProductsPage(ctx context.Context, r *pb.GetProductsRequest) (*pb.GetProductsResponse, error) {
+	page, err := s.service.GetProductsPage(ctx, r.Query, r.After, r.First)
+	if err != nil {
+		log.Println("Error getting products page:", err)
+		if errors.Is(err, cursor.ErrInvalid) || errors.Is(err, ErrCursorExpired) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, productError("", err)
+	}
+	pbProducts := make([]*pb.Product, 0, len(page.Products))
+	for _, product := range page.Products {
+		pbProducts = append(pbProducts, convertProductToProto(&product))
+	}
+	return &pb.GetProductsResponse{
+		Products:    pbProducts,
+		Cursors:     page.Cursors,
+		HasNextPage: page.HasNextPage,
+	}, nil
+}
+
+func (s *grpcServer) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.UpdateProductResponse, error) {
+	var update ProductUpdate
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case "name":
+			update.Name = &req.Name
+		case "description":
+			update.Description = &req.Description
+		case "price":
+			price := convertProtoToMoney(req.Price)
+			update.Price = &price
+		case "stock":
+			update.Stock = &req.Stock
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown update mask path %q", path)
+		}
+	}
+
+	product, err := s.service.UpdateProduct(ctx, req.Id, update)
+	if err != nil {
+		log.Println("Error updating product:", err)
+		return nil, productError(req.Id, err)
+	}
+	return &pb.UpdateProductResponse{
+		Product: convertProductToProto(product),
+	}, nil
+}
+
+func (s *grpcServer) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
+	if err := s.service.DeleteProduct(ctx, req.Id); err != nil {
+		log.Println("Error deleting product:", err)
+		return nil, productError(req.Id, err)
+	}
+	return &pb.DeleteProductResponse{}, nil
+}
+
+func (s *grpcServer) ReserveStock(ctx context.Context, req *pb.ReserveStockRequest) (*pb.ReserveStockResponse, error) {
+	items := make([]StockItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, StockItem{
+			ProductID: item.ProductId,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	reservation, err := s.service.ReserveStock(ctx, items, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		log.Println("Error reserving stock:", err)
+		return nil, reservationError(err)
+	}
+
+	pbItems := make([]*pb.StockItem, 0, len(reservation.Items))
+	for _, item := range reservation.Items {
+		pbItems = append(pbItems, &pb.StockItem{
+			ProductId: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+	return &pb.ReserveStockResponse{
+		Reservation: &pb.Reservation{
+			Id:        reservation.ID,
+			Items:     pbItems,
+			Status:    string(reservation.Status),
+			ExpiresAt: timestamppb.New(reservation.ExpiresAt),
+		},
+	}, nil
+}
+
+func (s *grpcServer) CommitReservation(ctx context.Context, req *pb.CommitReservationRequest) (*pb.CommitReservationResponse, error) {
+	if err := s.service.CommitReservation(ctx, req.ReservationId); err != nil {
+		log.Println("Error committing reservation:", err)
+		return nil, reservationError(err)
+	}
+	return &pb.CommitReservationResponse{}, nil
+}
+
+func (s *grpcServer) ReleaseReservation(ctx context.Context, req *pb.ReleaseReservationRequest) (*pb.ReleaseReservationResponse, error) {
+	if err := s.service.ReleaseReservation(ctx, req.ReservationId); err != nil {
+		log.Println("Error releasing reservation:", err)
+		return nil, reservationError(err)
+	}
+	return &pb.ReleaseReservationResponse{}, nil
+}
+
+// Helper: map product errors to gRPC status codes
+func productError(id string, err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Errorf(codes.NotFound, "product %q not found", id)
+	case errors.Is(err, ErrEmptyUpdate),
+		errors.Is(err, money.ErrInvalidCurrency),
+		errors.Is(err, money.ErrNegativeAmount):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrInsufficientStock):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return unexpectedError(err, "product request failed")
+	}
+}
+
+func reservationError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidReservation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrInsufficientStock),
+		errors.Is(err, ErrReservationExpired),
+		errors.Is(err, ErrReservationNotPending):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return unexpectedError(err, "reservation request failed")
+	}
+}
+
+// Helper: the status of errors the mappers above do not know. Timeouts keep
+// their code; anything else is internal and its details stay in the log.
+func unexpectedError(err error, message string) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), elastic.IsTimeout(err):
+		return status.Error(codes.DeadlineExceeded, message+": timed out")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, message+": canceled")
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+func convertProductToProto(p *Product) *pb.Product {
+	return &pb.Product{
+		Id:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       convertMoneyToProto(p.Price),
+		Stock:       p.Stock,
+		StockUntracked: p.StockUntracked,
+		Deleted:     p.Deleted,
+	}
+}
+
+func convertMoneyToProto(m money.Money) *pb.Money {
+	return &pb.Money{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+	}
+}
+
+func convertProtoToMoney(m *pb.Money) money.Money {
+	return money.New(m.GetAmount(), m.GetCurrency())
+}
@@ -0,0 +1,33 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestProductError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{ErrNotFound, codes.NotFound},
+		{ErrEmptyUpdate, codes.InvalidArgument},
+		{fmt.Errorf("%w: product p1", ErrInsufficientStock), codes.FailedPrecondition},
+		{fmt.Errorf("search: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{errors.New("elastic: connection refused"), codes.Internal},
+	}
+	for _, tt := range tests {
+		got := status.Convert(productError("p1", tt.err))
+		if got.Code() != tt.code {
+			t.Errorf("%v: got %s, want %s", tt.err, got.Code(), tt.code)
+		}
+		if tt.code == codes.Internal && got.Message() != "product request failed" {
+			t.Errorf("%v: internal error leaks %q", tt.err, got.Message())
+		}
+	}
+}
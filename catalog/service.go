@@ -2,41 +2,138 @@ package catalog
 
 import (
 	context "context"
+	"errors"
+	"fmt"
 	"log"
+	"time"
 
+	"github.com/pawan-sharma-12/go_microservices/cursor"
+	"github.com/pawan-sharma-12/go_microservices/money"
 	"github.com/segmentio/ksuid"
 )
 type Product struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	Price       float64 `json:"price"`
+	Price       money.Money `json:"price"`
+	Stock       uint64 `json:"stock"`
+	// StockUntracked is set on products indexed before stock was tracked.
+	// Their Stock is 0 and reservations do not take from it, until the
+	// stock is set.
+	StockUntracked bool `json:"stock_untracked,omitempty"`
+	Deleted     bool   `json:"deleted"`
 }
+
+// ProductUpdate holds the fields to change on a product. Nil fields are
+// left as they are.
+type ProductUpdate struct {
+	Name        *string
+	Description *string
+	Price       *money.Money
+	Stock       *uint64
+}
+
+// ProductCursor is a position in a product listing. SearchAfter holds the
+// sort values of the product before the position, and PitID the point in
+// time the listing reads from, if the backend has one.
+type ProductCursor struct {
+	Query       string        `json:"q,omitempty"`
+	PitID       string        `json:"pit,omitempty"`
+	SearchAfter []interface{} `json:"after"`
+}
+
+// ProductPage is one page of a cursor-paginated product listing
+type ProductPage struct {
+	Products []Product
+	// Cursors[i] is the position just after Products[i]
+	Cursors     []string
+	HasNextPage bool
+}
+
+type StockItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  uint64 `json:"quantity"`
+}
+
+type ReservationStatus string
+
+const (
+	ReservationPending   ReservationStatus = "pending"
+	ReservationCommitted ReservationStatus = "committed"
+	ReservationReleased  ReservationStatus = "released"
+	// ReservationReleasing is a release that has not given all its stock
+	// back yet
+	ReservationReleasing ReservationStatus = "releasing"
+)
+
+// Reservation holds stock for an order that is being placed. Pending
+// reservations that are neither committed nor released before ExpiresAt
+// give their stock back.
+type Reservation struct {
+	ID        string            `json:"id"`
+	Items     []StockItem       `json:"items"`
+	Status    ReservationStatus `json:"status"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+const (
+	DefaultReservationTTL = 15 * time.Minute
+	MaxReservationTTL     = time.Hour
+	// reservationSweepInterval is how often expired reservations are released
+	reservationSweepInterval = 30 * time.Second
+	// maxPageSize caps how many products one page returns
+	maxPageSize = 100
+)
+
+var (
+	ErrInvalidReservation = errors.New("invalid reservation")
+	ErrEmptyUpdate        = errors.New("update has no fields")
+)
 type catalogService struct {
 	repo Repository
+	stop chan struct{}
+	// swept is closed when the sweeper has returned
+	swept chan struct{}
 }
 type Service interface	 {
 	Close()
-	PostProduct(ctx context.Context, name string, description string, price float64) (*Product, error)
+	PostProduct(ctx context.Context, name string, description string, price money.Money, stock uint64) (*Product, error)
 	GetProduct(ctx context.Context, id string) (*Product, error)
 	GetProducts(ctx context.Context, skip uint64, take uint64) ([]Product, error)
 	GetProductsByIds(	ctx context.Context, ids []string) ([]Product, error)
 	SearchProducts (ctx context.Context, query string, skip uint64, take uint64) ([]Product, error)
+	GetProductsPage(ctx context.Context, query string, after string, first uint64) (*ProductPage, error)
+	UpdateProduct(ctx context.Context, id string, update ProductUpdate) (*Product, error)
+	DeleteProduct(ctx context.Context, id string) error
+	ReserveStock(ctx context.Context, items []StockItem, ttl time.Duration) (*Reservation, error)
+	CommitReservation(ctx context.Context, id string) error
+	ReleaseReservation(ctx context.Context, id string) error
 }	
 func NewService(repo Repository) Service {
-	return &catalogService{
-		repo: repo,
+	s := &catalogService{
+		repo:  repo,
+		stop:  make(chan struct{}),
+		swept: make(chan struct{}),
 	}
+	go s.sweepExpiredReservations()
+	return s
 }
+// Close waits for a sweep in progress before closing the repository
 func (s *catalogService) Close() {
+	close(s.stop)
+	<-s.swept
 	s.repo.Close()
 }
-func (s *catalogService) PostProduct(ctx context.Context, name string, description string, price float64) (*Product, error) {
+func (s *catalogService) PostProduct(ctx context.Context, name string, description string, price money.Money, stock uint64) (*Product, error) {
+	if err := price.Validate(); err != nil {
+		return nil, err
+	}
 	product := Product{
 		ID:          ksuid.New().String(),
 		Name:        name,
 		Description: description,
 		Price:       price,
+		Stock:       stock,
 	}
 	if err := s.repo.PutProduct(ctx, product); err != nil {
 		log.Println("Error with PutProduct : ",err)
@@ -63,4 +160,134 @@ func (s *catalogService) SearchProducts(ctx context.Context, query string, skip
 	return s.repo.SearchProducts(ctx, query, skip, take)
 }
 
+// GetProductsPage lists products after the cursor, or searches them if query
+// is set. Cursors only continue the listing they came from.
+func (s *catalogService) GetProductsPage(ctx context.Context, query string, after string, first uint64) (*ProductPage, error) {
+	if first == 0 || first > maxPageSize {
+		first = maxPageSize
+	}
+	var position *ProductCursor
+	if after != "" {
+		position = &ProductCursor{}
+		if err := cursor.Decode(after, position); err != nil {
+			return nil, err
+		}
+		if position.Query != query {
+			return nil, fmt.Errorf("%w: cursor belongs to a different query", cursor.ErrInvalid)
+		}
+	}
 
+	// One extra product tells whether there is a next page
+	products, positions, err := s.repo.ListProductsPage(ctx, query, position, first+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &ProductPage{HasNextPage: uint64(len(products)) > first}
+	if page.HasNextPage {
+		products = products[:first]
+		positions = positions[:first]
+	}
+	page.Products = products
+	page.Cursors = make([]string, len(positions))
+	for i, p := range positions {
+		page.Cursors[i] = cursor.Encode(p)
+	}
+	return page, nil
+}
+
+func (s *catalogService) UpdateProduct(ctx context.Context, id string, update ProductUpdate) (*Product, error) {
+	if update.Name == nil && update.Description == nil && update.Price == nil && update.Stock == nil {
+		return nil, ErrEmptyUpdate
+	}
+	if update.Price != nil {
+		if err := update.Price.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.UpdateProduct(ctx, id, update)
+}
+
+// DeleteProduct hides a product from listings and search. It stays
+// resolvable by ID list so historical orders can still show it.
+func (s *catalogService) DeleteProduct(ctx context.Context, id string) error {
+	return s.repo.DeleteProduct(ctx, id, time.Now().UTC())
+}
+
+func (s *catalogService) ReserveStock(ctx context.Context, items []StockItem, ttl time.Duration) (*Reservation, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: no items", ErrInvalidReservation)
+	}
+	// One entry per product, so a product is never decremented twice
+	merged := make([]StockItem, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		if item.Quantity == 0 {
+			return nil, fmt.Errorf("%w: quantity of product %s must be positive", ErrInvalidReservation, item.ProductID)
+		}
+		if i, ok := index[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(merged)
+		merged = append(merged, item)
+	}
+	if ttl <= 0 {
+		ttl = DefaultReservationTTL
+	}
+	if ttl > MaxReservationTTL {
+		ttl = MaxReservationTTL
+	}
+
+	reservation := Reservation{
+		ID:        ksuid.New().String(),
+		Items:     merged,
+		Status:    ReservationPending,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}
+	if err := s.repo.ReserveStock(ctx, reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+func (s *catalogService) CommitReservation(ctx context.Context, id string) error {
+	err := s.repo.CommitReservation(ctx, id, time.Now().UTC())
+	if errors.Is(err, ErrReservationExpired) {
+		// Give the stock back now instead of waiting for the sweeper
+		if relErr := s.repo.ReleaseReservation(ctx, id); relErr != nil && !errors.Is(relErr, ErrReservationNotPending) {
+			log.Println("Error releasing expired reservation:", relErr)
+		}
+	}
+	return err
+}
+
+func (s *catalogService) ReleaseReservation(ctx context.Context, id string) error {
+	return s.repo.ReleaseReservation(ctx, id)
+}
+
+// sweepExpiredReservations periodically releases reservations whose TTL has
+// passed, until the service is closed
+func (s *catalogService) sweepExpiredReservations() {
+	defer close(s.swept)
+	ticker := time.NewTicker(reservationSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), reservationSweepInterval)
+		ids, err := s.repo.ListExpiredReservations(ctx, time.Now().UTC())
+		if err != nil {
+			log.Println("Error listing expired reservations:", err)
+		}
+		for _, id := range ids {
+			if err := s.repo.ReleaseReservation(ctx, id); err != nil && !errors.Is(err, ErrReservationNotPending) {
+				log.Println("Error releasing expired reservation:", err)
+			}
+		}
+		cancel()
+	}
+}
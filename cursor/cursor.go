@@ -0,0 +1,39 @@
+// Package cursor turns pagination positions into opaque tokens that clients
+// pass back to fetch the next page.
+package cursor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalid = errors.New("invalid cursor")
+
+// Encode serializes a position. The token is URL safe but its content is not
+// part of any API and may change.
+func Encode(position any) string {
+	b, err := json.Marshal(position)
+	if err != nil {
+		// Positions are plain structs of strings and numbers
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode reads a token produced by Encode into position. Numbers are kept as
+// json.Number so large sort values survive the round trip.
+func Decode(token string, position any) error {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalid
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(position); err != nil {
+		return ErrInvalid
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Package graceful runs servers until their context is done, then lets
+// in-flight requests finish before stopping them.
+package graceful
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Timeout is how long servers let in-flight requests finish once their
+// context is done
+var Timeout = 15 * time.Second
+
+// TimeoutFromEnv sets Timeout from SHUTDOWN_TIMEOUT, if set
+func TimeoutFromEnv() error {
+	v := os.Getenv("SHUTDOWN_TIMEOUT")
+	if v == "" {
+		return nil
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		return errors.New("SHUTDOWN_TIMEOUT must be positive")
+	}
+	Timeout = timeout
+	return nil
+}
+
+// SignalContext is done on SIGINT or SIGTERM. A second signal kills the
+// process at once.
+func SignalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		// Let the next signal terminate the process
+		cancel()
+		log.Println("🛑 Shutting down, waiting for in-flight requests...")
+	}()
+	return ctx, cancel
+}
+
+// ServeGRPC serves srv on lis until ctx is done. It then stops accepting
+// calls and waits up to Timeout for those in flight before cutting them.
+func ServeGRPC(ctx context.Context, srv *grpc.Server, lis net.Listener) error {
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(lis) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := Timeout
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		log.Printf("⚠️ Calls still running after %s, stopping anyway", timeout)
+		srv.Stop()
+		<-stopped
+	}
+	return <-errc
+}
+
+// ServeHTTP serves srv on lis until ctx is done, then shuts it down,
+// waiting up to Timeout for requests in flight. Hijacked connections, such
+// as websockets, are not waited for.
+func ServeHTTP(ctx context.Context, srv *http.Server, lis net.Listener) error {
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(lis) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	timeout := Timeout
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Printf("⚠️ Requests still running after %s, closing anyway", timeout)
+		err = srv.Close()
+	}
+	if serveErr := <-errc; !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return err
+}
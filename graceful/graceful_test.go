@@ -0,0 +1,136 @@
+package graceful
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// blockingServer holds EmptyCall until release is closed, after telling
+// started
+type blockingServer struct {
+	testpb.UnimplementedTestServiceServer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingServer) EmptyCall(ctx context.Context, _ *testpb.Empty) (*testpb.Empty, error) {
+	close(s.started)
+	select {
+	case <-s.release:
+		return &testpb.Empty{}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startGRPC serves a blockingServer until cancel, and starts a call to it.
+// The returned channels get the errors of the call and of ServeGRPC.
+func startGRPC(t *testing.T) (context.CancelFunc, *blockingServer, <-chan error, <-chan error) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	blocking := &blockingServer{started: make(chan struct{}), release: make(chan struct{})}
+	testpb.RegisterTestServiceServer(srv, blocking)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- ServeGRPC(ctx, srv, lis) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	called := make(chan error, 1)
+	go func() {
+		_, err := testpb.NewTestServiceClient(conn).EmptyCall(context.Background(), &testpb.Empty{})
+		called <- err
+	}()
+	<-blocking.started
+	return cancel, blocking, called, served
+}
+
+func TestServeGRPC(t *testing.T) {
+	defer func(timeout time.Duration) { Timeout = timeout }(Timeout)
+
+	t.Run("drains", func(t *testing.T) {
+		Timeout = time.Minute
+		cancel, blocking, called, served := startGRPC(t)
+		cancel()
+		select {
+		case err := <-served:
+			t.Fatalf("stopped with a call in flight: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(blocking.release)
+		if err := <-called; err != nil {
+			t.Errorf("call in flight: %v", err)
+		}
+		if err := <-served; err != nil {
+			t.Errorf("ServeGRPC: %v", err)
+		}
+	})
+
+	t.Run("forces stop after Timeout", func(t *testing.T) {
+		Timeout = 50 * time.Millisecond
+		cancel, _, called, served := startGRPC(t)
+		cancel()
+		if err := <-served; err != nil {
+			t.Errorf("ServeGRPC: %v", err)
+		}
+		if err := <-called; status.Code(err) != codes.Unavailable && status.Code(err) != codes.Canceled {
+			t.Errorf("cut call: got %v, want Unavailable or Canceled", err)
+		}
+	})
+}
+
+func TestServeHTTP(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- ServeHTTP(ctx, srv, lis) }()
+
+	body := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + lis.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		b, _ := io.ReadAll(res.Body)
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+	close(release)
+	if got := <-body; got != "done" {
+		t.Errorf("request in flight: got %q", got)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("ServeHTTP: %v", err)
+	}
+}
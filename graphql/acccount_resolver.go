@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"log"
+	"strings"
 	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/order"
 )
 
 // AccountResolver is defined as a struct
@@ -11,33 +14,88 @@ type accountResolver struct{
 	server *Server
 }
 
-func (r *accountResolver) Orders(ctx context.Context, obj *Account) ([]*Order, error) {
+func (r *accountResolver) Orders(ctx context.Context, obj *Account, filter *OrderFilterInput, sort *OrderSort, first *int, after *string) ([]*Order, error) {
 	// Implementation goes here
-	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
-	defer cancel()
-	orderList , err := r.server.orderClient.GetOrdersForAccount(ctx, obj.ID)
+	page , err := r.server.ordersPage(ctx, obj.ID, filter, sort, first, after)
 	if err != nil{
 		log.Println("Error resolving Orders in account_resolver  : ", err)
 		return  nil, err
 	}
-	var orders []*Order
-	for _, o := range orderList {
-		var products []*OrderProduct
-		for _, p := range o.Products{
-			products = append(products, &OrderProduct{
-				ID: p.ID,
-				Name: p.Name,
-				Description: p.Description,
-				Price: p.Price,
-				Quantity: int(p.Quantity),
-			})
-		}
-		orders = append(orders, &Order{
-			ID : o.ID,
-			CreatedAt : o.CreatedAt,
-			TotalPrice : o.TotalPrice,
-			Products : products,
-		})
+	orders := make([]*Order, 0, len(page.Orders))
+	for i := range page.Orders {
+		orders = append(orders, toGraphQLOrder(&page.Orders[i]))
 	}
 	return orders, nil 
-}
\ No newline at end of file
+}
+
+func (r *accountResolver) OrdersConnection(ctx context.Context, obj *Account, filter *OrderFilterInput, sort *OrderSort, first *int, after *string) (*OrderConnection, error) {
+	page, err := r.server.ordersPage(ctx, obj.ID, filter, sort, first, after)
+	if err != nil {
+		log.Println("Error resolving OrdersConnection in account_resolver  : ", err)
+		return nil, err
+	}
+
+	edges := make([]*OrderEdge, 0, len(page.Orders))
+	for i := range page.Orders {
+		edges = append(edges, &OrderEdge{
+			Cursor: page.Cursors[i],
+			Node:   toGraphQLOrder(&page.Orders[i]),
+		})
+	}
+	return &OrderConnection{
+		Edges:    edges,
+		PageInfo: toPageInfo(page.Cursors, page.HasNextPage),
+	}, nil
+}
+
+// Helper: a page of orders, of one account if accountID is set. First pages
+// of an account go through the request's loader, so listing the orders of
+// many accounts takes one call.
+func (s *Server) ordersPage(ctx context.Context, accountID string, filter *OrderFilterInput, sort *OrderSort, first *int, after *string) (*order.OrderPage, error) {
+	size, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	if accountID != "" && derefString(after) == "" {
+		loader := s.loaders(ctx).AccountOrders(toOrderFilter(accountID, filter), toOrderSort(sort), size)
+		page, err := loader.Load(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			return &order.OrderPage{}, nil
+		}
+		return page, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	return s.orderClient.ListOrders(ctx, toOrderFilter(accountID, filter), toOrderSort(sort), derefString(after), size)
+}
+
+// Order helper: convert GraphQL listing arguments to an order service filter
+func toOrderFilter(accountID string, in *OrderFilterInput) order.OrderFilter {
+	filter := order.OrderFilter{AccountID: accountID}
+	if in == nil {
+		return filter
+	}
+	if in.CreatedAfter != nil {
+		filter.CreatedAfter = *in.CreatedAfter
+	}
+	if in.CreatedBefore != nil {
+		filter.CreatedBefore = *in.CreatedBefore
+	}
+	for _, s := range in.Statuses {
+		filter.Statuses = append(filter.Statuses, order.OrderStatus(strings.ToLower(string(s))))
+	}
+	filter.MinTotal = in.MinTotal
+	filter.MaxTotal = in.MaxTotal
+	return filter
+}
+
+func toOrderSort(sort *OrderSort) order.OrderSort {
+	if sort == nil {
+		return ""
+	}
+	return order.OrderSort(strings.ToLower(string(*sort)))
+}
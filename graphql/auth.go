@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/pawan-sharma-12/go_microservices/account"
+	"github.com/pawan-sharma-12/go_microservices/auth"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("not allowed")
+)
+
+// keyRefreshInterval limits how often a token with an unknown key ID makes
+// the gateway fetch the account service's public keys again
+const keyRefreshInterval = 30 * time.Second
+
+// Principal is the account a request is made for
+type Principal struct {
+	AccountID string
+	Role      account.AccountRole
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.Role == account.RoleAdmin
+}
+
+type principalKey struct{}
+
+// principalFrom returns the principal of a request, nil if it carried no
+// token
+func principalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// withPrincipal also marks the calls made for the request as made for the
+// principal, so that services can check its role too
+func withPrincipal(ctx context.Context, p *Principal) context.Context {
+	ctx = auth.WithUser(ctx, p.AccountID, string(p.Role))
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// keySet caches the public keys of the account service. A token signed with
+// a key it does not know yet, as after a key rotation, makes it fetch them
+// again, at most once every keyRefreshInterval.
+type keySet struct {
+	fetch func(ctx context.Context) (map[string]ed25519.PublicKey, error)
+
+	mu        sync.Mutex
+	keys      map[string]ed25519.PublicKey
+	fetchedAt time.Time
+}
+
+func (k *keySet) key(ctx context.Context, kid string) (ed25519.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	if !k.fetchedAt.IsZero() && time.Since(k.fetchedAt) < keyRefreshInterval {
+		return nil, errors.New("unknown signing key")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	keys, err := k.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	k.keys, k.fetchedAt = keys, time.Now()
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, errors.New("unknown signing key")
+}
+
+// AuthMiddleware checks the bearer token of a request and puts its principal
+// in the context. Requests without a token go through anonymously; those
+// with a bad or expired one are turned away, so clients know to refresh it.
+// Websocket clients may send the token in the connection_init payload
+// instead, see websocketInit.
+func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p, err := s.authenticate(r.Context(), header)
+		if err != nil {
+			writeUnauthenticated(w)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), p)))
+	})
+}
+
+// websocketInit authenticates a websocket connection with the Authorization
+// field of its connection_init payload, unless the upgrade request already
+// carried a token
+func (s *Server) websocketInit(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	header := payload.Authorization()
+	if header == "" || principalFrom(ctx) != nil {
+		return ctx, nil, nil
+	}
+	p, err := s.authenticate(ctx, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return withPrincipal(ctx, p), nil, nil
+}
+
+// Helper: the principal of an Authorization header value
+func (s *Server) authenticate(ctx context.Context, header string) (*Principal, error) {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return nil, account.ErrInvalidToken
+	}
+	claims, err := s.tokens.ParseAccessToken(ctx, strings.TrimSpace(token))
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{AccountID: claims.Subject, Role: claims.Role}, nil
+}
+
+// Helper: answer like a GraphQL error, with a 401 status
+func writeUnauthenticated(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"message":    "invalid or expired token",
+			"extensions": map[string]string{"code": codeUnauthenticated},
+		}},
+	})
+}
+
+// authDirective implements @auth. Every role needs a principal; ADMIN needs
+// an admin, and OWNER the account owning what the field acts on, found
+// before the field resolves (see ownerOf). The order query only reads, so
+// its order is checked once resolved instead of being looked up twice.
+// Admins pass every check.
+func (s *Server) authDirective(ctx context.Context, obj interface{}, next graphql.Resolver, role *Role) (interface{}, error) {
+	p := principalFrom(ctx)
+	if p == nil {
+		return nil, ErrUnauthenticated
+	}
+	if p.IsAdmin() || role == nil || *role == RoleUser {
+		return next(ctx)
+	}
+	if *role == RoleAdmin {
+		return nil, ErrForbidden
+	}
+
+	if fc := graphql.GetFieldContext(ctx); fc != nil && fc.Object == "Query" && fc.Field.Name == "order" {
+		res, err := next(ctx)
+		if o, ok := res.(*Order); ok && o != nil && o.AccountID != p.AccountID {
+			return nil, ErrForbidden
+		}
+		return res, err
+	}
+
+	owner, err := s.ownerOf(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	if owner != p.AccountID {
+		return nil, ErrForbidden
+	}
+	return next(ctx)
+}
+
+// ownerOf returns the account owning what an OWNER field acts on: the
+// account the field belongs to or names, or the owner of the order it
+// names.
+// Fields it does not know are refused, so that a new OWNER field is closed
+// until its owner is looked up here.
+func (s *Server) ownerOf(ctx context.Context, obj interface{}) (string, error) {
+	if a, ok := obj.(*Account); ok && a != nil {
+		return a.ID, nil
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil {
+		return "", ErrForbidden
+	}
+	switch fc.Object + "." + fc.Field.Name {
+	case "Mutation.updateAccount", "Mutation.deactivateAccount", "Mutation.reactivateAccount", "Mutation.deleteAccount":
+		id, _ := fc.Args["id"].(string)
+		return parseID(accountType, id)
+	case "Mutation.createOrder":
+		in, _ := fc.Args["order"].(OrderInput)
+		return parseID(accountType, in.AccountID)
+	case "Mutation.cancelOrder":
+		id, _ := fc.Args["id"].(string)
+		return s.orderOwner(ctx, id)
+	default:
+		return "", ErrForbidden
+	}
+}
+
+// Helper: the account owning an order, by global or plain ID
+func (s *Server) orderOwner(ctx context.Context, id string) (string, error) {
+	id, err := parseID(orderType, id)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	o, err := s.orderClient.GetOrder(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return o.AccountID, nil
+}
+
+// authorizeAccount checks that the principal may see an account's orders
+func authorizeAccount(ctx context.Context, accountID string) error {
+	p := principalFrom(ctx)
+	if p == nil {
+		return ErrUnauthenticated
+	}
+	if !p.IsAdmin() && p.AccountID != accountID {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/pawan-sharma-12/go_microservices/account"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// newAuthServer returns a server checking tokens of issuer, with the keys
+// fetched the way the gateway fetches them from the account service
+func newAuthServer(t *testing.T) (*Server, *account.TokenIssuer) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := account.NewTokenIssuer(key, "test", time.Minute, time.Hour)
+	keys := &keySet{fetch: func(ctx context.Context) (map[string]ed25519.PublicKey, error) {
+		return issuer.PublicKeys(), nil
+	}}
+	return &Server{tokens: account.NewTokenVerifier("test", keys.key)}, issuer
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	s, issuer := newAuthServer(t)
+	tokens, err := issuer.Issue(&account.Account{ID: "alice", Role: account.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *Principal
+	h := s.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = principalFrom(r.Context())
+	}))
+	serve := func(header string) *httptest.ResponseRecorder {
+		got = nil
+		r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve(""); w.Code != http.StatusOK || got != nil {
+		t.Errorf("no token: got status %d and principal %+v, want anonymous", w.Code, got)
+	}
+	if w := serve("Bearer " + tokens.AccessToken); w.Code != http.StatusOK || got == nil || got.AccountID != "alice" || !got.IsAdmin() {
+		t.Errorf("valid token: got status %d and principal %+v", w.Code, got)
+	}
+	for _, header := range []string{"Bearer " + tokens.RefreshToken, "Bearer nonsense", tokens.AccessToken} {
+		w := serve(header)
+		if w.Code != http.StatusUnauthorized || got != nil || !strings.Contains(w.Body.String(), codeUnauthenticated) {
+			t.Errorf("%.20q: got status %d, body %s", header, w.Code, w.Body)
+		}
+	}
+}
+
+func TestAuthDirective(t *testing.T) {
+	user := &Principal{AccountID: "alice", Role: account.RoleUser}
+	admin := &Principal{AccountID: "root", Role: account.RoleAdmin}
+	role := func(r Role) *Role { return &r }
+	field := func(object, name string) *graphql.FieldContext {
+		return &graphql.FieldContext{Object: object, Field: graphql.CollectedField{Field: &ast.Field{Name: name}}}
+	}
+	withArgs := func(fc *graphql.FieldContext, args map[string]interface{}) *graphql.FieldContext {
+		fc.Args = args
+		return fc
+	}
+	ownID := map[string]interface{}{"id": "alice"}
+	otherID := map[string]interface{}{"id": toGlobalID(accountType, "bob")}
+	tests := []struct {
+		name      string
+		principal *Principal
+		role      *Role
+		obj       interface{}
+		field     *graphql.FieldContext
+		wantErr   error
+	}{
+		{"anonymous", nil, role(RoleUser), nil, nil, ErrUnauthenticated},
+		{"user", user, role(RoleUser), nil, nil, nil},
+		{"user as admin", user, role(RoleAdmin), nil, nil, ErrForbidden},
+		{"admin", admin, role(RoleAdmin), nil, nil, nil},
+		{"own account", user, role(RoleOwner), &Account{ID: "alice"}, field("Account", "orders"), nil},
+		{"other account", user, role(RoleOwner), &Account{ID: "bob"}, field("Account", "orders"), ErrForbidden},
+		{"admin on other account", admin, role(RoleOwner), &Account{ID: "bob"}, field("Account", "orders"), nil},
+		{"own account argument", user, role(RoleOwner), nil, withArgs(field("Mutation", "deleteAccount"), ownID), nil},
+		{"other account argument", user, role(RoleOwner), nil, withArgs(field("Mutation", "updateAccount"), otherID), ErrForbidden},
+		{"order for own account", user, role(RoleOwner), nil, withArgs(field("Mutation", "createOrder"), map[string]interface{}{"order": OrderInput{AccountID: "alice"}}), nil},
+		{"order for other account", user, role(RoleOwner), nil, withArgs(field("Mutation", "createOrder"), map[string]interface{}{"order": OrderInput{AccountID: "bob"}}), ErrForbidden},
+		{"unknown owner", user, role(RoleOwner), nil, field("Query", "something"), ErrForbidden},
+		{"no field", user, role(RoleOwner), nil, nil, ErrForbidden},
+	}
+	s := &Server{}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.principal != nil {
+			ctx = withPrincipal(ctx, tt.principal)
+		}
+		if tt.field != nil {
+			ctx = graphql.WithFieldContext(ctx, tt.field)
+		}
+		resolved := false
+		got, err := s.authDirective(ctx, tt.obj, func(ctx context.Context) (interface{}, error) {
+			resolved = true
+			return "ok", nil
+		}, tt.role)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if tt.wantErr == nil && got != "ok" {
+			t.Errorf("%s: got %v, want ok", tt.name, got)
+		}
+		if tt.wantErr != nil && resolved {
+			t.Errorf("%s: field resolved before it was refused", tt.name)
+		}
+	}
+}
+
+func TestAuthDirectiveOrderQuery(t *testing.T) {
+	user := &Principal{AccountID: "alice", Role: account.RoleUser}
+	owner := RoleOwner
+	ctx := graphql.WithFieldContext(withPrincipal(context.Background(), user),
+		&graphql.FieldContext{Object: "Query", Field: graphql.CollectedField{Field: &ast.Field{Name: "order"}}})
+	s := &Server{}
+	resolve := func(o *Order) (interface{}, error) {
+		return s.authDirective(ctx, nil, func(ctx context.Context) (interface{}, error) { return o, nil }, &owner)
+	}
+
+	if got, err := resolve(&Order{ID: "o1", AccountID: "alice"}); err != nil || got.(*Order).ID != "o1" {
+		t.Errorf("own order: got %v, %v", got, err)
+	}
+	if _, err := resolve(&Order{ID: "o2", AccountID: "bob"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("other account's order: got %v, want ErrForbidden", err)
+	}
+	if got, err := resolve(nil); err != nil || got.(*Order) != nil {
+		t.Errorf("missing order: got %v, %v, want null", got, err)
+	}
+}
+
+func TestKeySetRefresh(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	public := key.Public().(ed25519.PublicKey)
+	fetches := 0
+	keys := &keySet{fetch: func(ctx context.Context) (map[string]ed25519.PublicKey, error) {
+		fetches++
+		return map[string]ed25519.PublicKey{"current": public}, nil
+	}}
+	ctx := context.Background()
+
+	if _, err := keys.key(ctx, "current"); err != nil {
+		t.Fatalf("known key: %v", err)
+	}
+	if _, err := keys.key(ctx, "current"); err != nil || fetches != 1 {
+		t.Errorf("cached key: got %v after %d fetches, want 1", err, fetches)
+	}
+	if _, err := keys.key(ctx, "rotated"); err == nil || fetches != 1 {
+		t.Errorf("unknown key right after a fetch: got %v after %d fetches, want an error and 1", err, fetches)
+	}
+	keys.fetchedAt = time.Now().Add(-keyRefreshInterval)
+	if _, err := keys.key(ctx, "rotated"); err == nil || fetches != 2 {
+		t.Errorf("unknown key later: got %v after %d fetches, want an error and 2", err, fetches)
+	}
+}
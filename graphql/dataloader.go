@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Loader coalesces lookups of single keys made within a short wait, as
+// happens when gqlgen resolves the same field for every element of a list,
+// into one call of its fetch function. Results are kept for the life of the
+// loader, which is one request.
+type Loader[K comparable, V any] struct {
+	// fetch returns the values of the given keys. Keys missing from the map
+	// load as the zero value.
+	fetch    func(ctx context.Context, keys []K) (map[K]V, error)
+	ctx      context.Context
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	results map[K]*loaderResult[V]
+	batch   *loaderBatch[K, V]
+}
+
+type loaderResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	results []*loaderResult[V]
+	sent    bool
+}
+
+// NewLoader returns a loader whose fetches run with ctx. A batch is sent
+// wait after its first key, or as soon as it holds maxBatch keys.
+func NewLoader[K comparable, V any](ctx context.Context, wait time.Duration, maxBatch int, fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		ctx:      ctx,
+		wait:     wait,
+		maxBatch: maxBatch,
+		results:  make(map[K]*loaderResult[V]),
+	}
+}
+
+// Load returns the value of key, fetching it with the other keys loaded
+// around the same time
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	if !ok {
+		r = &loaderResult[V]{done: make(chan struct{})}
+		l.results[key] = r
+
+		b := l.batch
+		if b == nil {
+			b = &loaderBatch[K, V]{}
+			l.batch = b
+			time.AfterFunc(l.wait, func() { l.send(b) })
+		}
+		b.keys = append(b.keys, key)
+		b.results = append(b.results, r)
+		if len(b.keys) >= l.maxBatch {
+			l.batch = nil
+			go l.send(b)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// send fetches a batch once, whichever of the timer and a full batch comes
+// first
+func (l *Loader[K, V]) send(b *loaderBatch[K, V]) {
+	l.mu.Lock()
+	if b.sent {
+		l.mu.Unlock()
+		return
+	}
+	b.sent = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	values, err := l.fetch(l.ctx, b.keys)
+	for i, key := range b.keys {
+		r := b.results[i]
+		if err != nil {
+			r.err = err
+		} else {
+			r.value = values[key]
+		}
+		close(r.done)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoaderBatchesConcurrentLoads(t *testing.T) {
+	ctx := context.Background()
+	var (
+		mu      sync.Mutex
+		batches [][]int
+	)
+	loader := NewLoader(ctx, 10*time.Millisecond, 100, func(ctx context.Context, keys []int) (map[int]int, error) {
+		mu.Lock()
+		batches = append(batches, slices.Clone(keys))
+		mu.Unlock()
+		values := map[int]int{}
+		for _, k := range keys {
+			if k != 3 {
+				values[k] = k * 10
+			}
+		}
+		return values, nil
+	})
+
+	// Key 1 is loaded twice but fetched once; key 3 is unknown
+	keys := []int{1, 2, 3, 4, 1}
+	got := make([]int, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := loader.Load(ctx, key)
+			if err != nil {
+				t.Errorf("Load: %v", err)
+			}
+			got[i] = v
+		}()
+	}
+	wg.Wait()
+
+	if want := []int{10, 20, 0, 40, 10}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if len(batches) != 1 || len(batches[0]) != 4 {
+		t.Errorf("got batches %v, want one batch of 4 keys", batches)
+	}
+
+	// Loaded keys are not fetched again
+	if v, _ := loader.Load(ctx, 2); v != 20 || len(batches) != 1 {
+		t.Errorf("second load got %d after %d batches", v, len(batches))
+	}
+}
+
+func TestLoaderMaxBatchAndErrors(t *testing.T) {
+	ctx := context.Background()
+	fail := errors.New("unavailable")
+	var calls sync.WaitGroup
+	calls.Add(2)
+	loader := NewLoader(ctx, time.Hour, 2, func(ctx context.Context, keys []string) (map[string]bool, error) {
+		defer calls.Done()
+		return nil, fail
+	})
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := loader.Load(ctx, key); !errors.Is(err, fail) {
+				t.Errorf("Load(%s): got %v, want the fetch error", key, err)
+			}
+		}()
+	}
+	// Full batches are sent without waiting for the timer
+	calls.Wait()
+	wg.Wait()
+}
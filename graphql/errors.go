@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error codes reported in extensions.code
+const (
+	codeBadUserInput       = "BAD_USER_INPUT"
+	codeNotFound           = "NOT_FOUND"
+	codeAlreadyExists      = "ALREADY_EXISTS"
+	codeFailedPrecondition = "FAILED_PRECONDITION"
+	codeUnauthenticated    = "UNAUTHENTICATED"
+	codeForbidden          = "FORBIDDEN"
+	codeTimeout            = "TIMEOUT"
+	codeUnavailable        = "SERVICE_UNAVAILABLE"
+	codeInternal           = "INTERNAL_SERVER_ERROR"
+)
+
+// errorPresenter turns resolver errors into GraphQL errors with the field
+// path and a code in extensions.code. Errors the client can act on keep the
+// message of the service that raised them; anything else is logged and
+// reported as an internal error without details.
+func errorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	cause := gqlErr.Unwrap()
+	if cause == nil {
+		// Parse and validation errors of gqlgen are already safe to show
+		return gqlErr
+	}
+
+	code, message := classifyError(cause)
+	if code == codeInternal {
+		log.Printf("❌ Internal error at %v: %v", gqlErr.Path, cause)
+	}
+	gqlErr.Message = message
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = map[string]interface{}{}
+	}
+	gqlErr.Extensions["code"] = code
+	return gqlErr
+}
+
+// Helper: the code and client-safe message of an error returned by a
+// resolver, either a gRPC status from a service or an error of the gateway
+func classifyError(err error) (code string, message string) {
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		st := grpcErr.GRPCStatus()
+		switch st.Code() {
+		case codes.InvalidArgument, codes.OutOfRange:
+			return codeBadUserInput, st.Message()
+		case codes.NotFound:
+			return codeNotFound, st.Message()
+		case codes.AlreadyExists:
+			return codeAlreadyExists, st.Message()
+		case codes.FailedPrecondition:
+			return codeFailedPrecondition, st.Message()
+		case codes.Unauthenticated:
+			return codeUnauthenticated, st.Message()
+		case codes.PermissionDenied:
+			return codeForbidden, st.Message()
+		case codes.DeadlineExceeded:
+			return codeTimeout, "the request timed out"
+		case codes.Unavailable:
+			return codeUnavailable, "a backend service is unavailable"
+		default:
+			return codeInternal, "internal server error"
+		}
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidParameter):
+		return codeBadUserInput, err.Error()
+	case errors.Is(err, ErrUnauthenticated):
+		return codeUnauthenticated, err.Error()
+	case errors.Is(err, ErrForbidden):
+		return codeForbidden, err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return codeTimeout, "the request timed out"
+	default:
+		return codeInternal, "internal server error"
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorPresenter(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{"not found", status.Error(codes.NotFound, `account "a" not found`), codeNotFound, `account "a" not found`},
+		{"invalid argument", status.Error(codes.InvalidArgument, "bad sort"), codeBadUserInput, "bad sort"},
+		{"already exists", status.Error(codes.AlreadyExists, "duplicate id"), codeAlreadyExists, "duplicate id"},
+		{"failed precondition", status.Error(codes.FailedPrecondition, "account has open orders"), codeFailedPrecondition, "account has open orders"},
+		{"wrapped status", fmt.Errorf("loading: %w", status.Error(codes.NotFound, "gone")), codeNotFound, "gone"},
+		{"gateway input", fmt.Errorf("%w: first must not be negative", ErrInvalidParameter), codeBadUserInput, "invalid parameter: first must not be negative"},
+		{"not logged in", ErrUnauthenticated, codeUnauthenticated, "authentication required"},
+		{"wrong role", ErrForbidden, codeForbidden, "not allowed"},
+		{"unknown status", status.Error(codes.Unknown, "sql: no rows in result set"), codeInternal, "internal server error"},
+		{"plain error", errors.New("dial tcp: connection refused"), codeInternal, "internal server error"},
+	}
+	for _, tt := range tests {
+		got := errorPresenter(context.Background(), tt.err)
+		if got.Message != tt.wantMessage || got.Extensions["code"] != tt.wantCode {
+			t.Errorf("%s: got %q with code %v, want %q with code %s", tt.name, got.Message, got.Extensions["code"], tt.wantMessage, tt.wantCode)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 "github.com/99designs/gqlgen/graphql"
 "github.com/pawan-sharma-12/go_microservices/account"
+"github.com/pawan-sharma-12/go_microservices/auth"
 "github.com/pawan-sharma-12/go_microservices/catalog"
 "github.com/pawan-sharma-12/go_microservices/order"
 )
@@ -15,33 +16,46 @@ type Server struct {
 	accountClient *account.Client
 	catalogClient *catalog.Client
 	orderClient   *order.Client
+	// tokens checks access tokens against the account service's keys
+	tokens *account.TokenVerifier
 }
-func NewGraphQlServer(accountUrl, catalogUrl, orderUrl string) (*Server, error) {
-    accountClient, err := account.NewClient(accountUrl)
+// NewGraphQlServer connects to the services, signing calls with authn.
+// authIssuer is the issuer access tokens must name.
+func NewGraphQlServer(accountUrl, catalogUrl, orderUrl, authIssuer string, authn *auth.Authenticator) (*Server, error) {
+    accountClient, err := account.NewClient(accountUrl, authn)
     if err != nil {
         return nil, err
     }
 
-    catalogClient, err := catalog.NewClient(catalogUrl)
+    catalogClient, err := catalog.NewClient(catalogUrl, authn)
     if err != nil {
         accountClient.Close() // close already created client
         return nil, err
     }
 
-    orderClient, err := order.NewClient(orderUrl)
+    orderClient, err := order.NewClient(orderUrl, authn)
     if err != nil {
         accountClient.Close()
         catalogClient.Close()
         return nil, err
     }
 
+    keys := &keySet{fetch: accountClient.GetPublicKeys}
     return &Server{
         accountClient: accountClient,
         catalogClient: catalogClient,
         orderClient:   orderClient,
+        tokens:        account.NewTokenVerifier(authIssuer, keys.key),
     }, nil
 }
 
+// Close closes the connections to the services
+func (s *Server) Close() {
+    s.accountClient.Close()
+    s.catalogClient.Close()
+    s.orderClient.Close()
+}
+
 func (s *Server) Mutation() MutationResolver {
 	return &mutationResolver{
 		server: s,
@@ -63,8 +77,29 @@ func (s *Server) Account() AccountResolver {
 	}
 }
 
+func (s *Server) Subscription() SubscriptionResolver {
+	return &subscriptionResolver{
+		server: s,
+	}
+}
+
+func (s *Server) Order() OrderResolver {
+	return &orderResolver{
+		server: s,
+	}
+}
+
+func (s *Server) OrderProduct() OrderProductResolver {
+	return &orderProductResolver{
+		server: s,
+	}
+}
+
 func (s *Server) ToExecutableSchema() graphql.ExecutableSchema {
 	return NewExecutableSchema(Config{
 		Resolvers: s,
+		Directives: DirectiveRoot{
+			Auth: s.authDirective,
+		},
 	})
 }
\ No newline at end of file
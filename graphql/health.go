@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/health"
+)
+
+// readyTimeout bounds the health checks of the services behind /readyz
+const readyTimeout = 3 * time.Second
+
+// livez answers as long as the gateway runs. It leaves the services out:
+// restarting the gateway would not bring them back.
+func livez(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ok\n"))
+}
+
+// ReadyzHandler reports the gateway ready while the account, catalog and
+// order services are all serving
+func (s *Server) ReadyzHandler() http.Handler {
+	return readyz(health.Checks{
+		"account": s.accountClient.Health,
+		"catalog": s.catalogClient.Health,
+		"order":   s.orderClient.Health,
+	})
+}
+
+// readyz answers 200 when all checks pass and 503 otherwise, with the
+// result of each check
+func readyz(checks health.Checks) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			services = make(map[string]string, len(checks))
+			ready    = true
+		)
+		for name, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result := "ok"
+				if err := check(ctx); err != nil {
+					result = err.Error()
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				services[name] = result
+				ready = ready && result == "ok"
+			}()
+		}
+		wg.Wait()
+
+		status, code := "ready", http.StatusOK
+		if !ready {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":   status,
+			"services": services,
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pawan-sharma-12/go_microservices/health"
+)
+
+func TestReadyz(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("catalog.CatalogService is NOT_SERVING") }
+
+	tests := []struct {
+		name     string
+		checks   health.Checks
+		code     int
+		status   string
+		services map[string]string
+	}{
+		{"all serving", health.Checks{"account": ok, "catalog": ok}, http.StatusOK, "ready",
+			map[string]string{"account": "ok", "catalog": "ok"}},
+		{"one down", health.Checks{"account": ok, "catalog": down}, http.StatusServiceUnavailable, "unavailable",
+			map[string]string{"account": "ok", "catalog": "catalog.CatalogService is NOT_SERVING"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			readyz(tt.checks)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.code {
+				t.Errorf("code: got %d, want %d", rec.Code, tt.code)
+			}
+			var body struct {
+				Status   string            `json:"status"`
+				Services map[string]string `json:"services"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != tt.status {
+				t.Errorf("status: got %q, want %q", body.Status, tt.status)
+			}
+			for name, want := range tt.services {
+				if body.Services[name] != want {
+					t.Errorf("%s: got %q, want %q", name, body.Services[name], want)
+				}
+			}
+		})
+	}
+}
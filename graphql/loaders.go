@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pawan-sharma-12/go_microservices/account"
+	"github.com/pawan-sharma-12/go_microservices/catalog"
+	"github.com/pawan-sharma-12/go_microservices/order"
+)
+
+// Lookups made within loaderWait of each other share one RPC of at most
+// loaderMaxBatch keys
+const (
+	loaderWait     = 2 * time.Millisecond
+	loaderMaxBatch = 100
+)
+
+type loadersKey struct{}
+
+// Loaders batch the service lookups of one GraphQL request
+type Loaders struct {
+	Accounts *Loader[string, *account.Account]
+	Products *Loader[string, *catalog.Product]
+
+	server *Server
+	ctx    context.Context
+
+	mu sync.Mutex
+	// accountOrders holds one loader per set of listing arguments
+	accountOrders map[string]*Loader[string, *order.OrderPage]
+}
+
+// LoadersMiddleware gives every request its own loaders, so lookups are
+// batched within a request but never shared between requests. Websocket
+// connections get none: they live for many subscription events, and cached
+// results would go stale.
+func (s *Server) LoadersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := context.WithValue(r.Context(), loadersKey{}, s.newLoaders(r.Context()))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// loaders returns the loaders of the current request. Without the middleware
+// every call gets fresh loaders, which still work but batch nothing.
+func (s *Server) loaders(ctx context.Context) *Loaders {
+	if l, ok := ctx.Value(loadersKey{}).(*Loaders); ok {
+		return l
+	}
+	return s.newLoaders(ctx)
+}
+
+func (s *Server) newLoaders(ctx context.Context) *Loaders {
+	return &Loaders{
+		Accounts:      NewLoader(ctx, loaderWait, loaderMaxBatch, s.fetchAccounts),
+		Products:      NewLoader(ctx, loaderWait, loaderMaxBatch, s.fetchProducts),
+		server:        s,
+		ctx:           ctx,
+		accountOrders: make(map[string]*Loader[string, *order.OrderPage]),
+	}
+}
+
+// AccountOrders returns the loader of the first order page of accounts,
+// listed with the given arguments. filter.AccountID is ignored.
+func (l *Loaders) AccountOrders(filter order.OrderFilter, sortBy order.OrderSort, first uint64) *Loader[string, *order.OrderPage] {
+	filter.AccountID = ""
+	key, _ := json.Marshal(struct {
+		Filter order.OrderFilter
+		Sort   order.OrderSort
+		First  uint64
+	}{filter, sortBy, first})
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	loader, ok := l.accountOrders[string(key)]
+	if !ok {
+		loader = NewLoader(l.ctx, loaderWait, loaderMaxBatch, func(ctx context.Context, accountIDs []string) (map[string]*order.OrderPage, error) {
+			ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+			defer cancel()
+			return l.server.orderClient.ListOrdersForAccounts(ctx, accountIDs, filter, sortBy, first)
+		})
+		l.accountOrders[string(key)] = loader
+	}
+	return loader
+}
+
+// Loader helper: fetch accounts by ID, unknown ones load as nil
+func (s *Server) fetchAccounts(ctx context.Context, ids []string) (map[string]*account.Account, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	accounts, err := s.accountClient.GetAccountsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*account.Account, len(accounts))
+	for i := range accounts {
+		byID[accounts[i].ID] = &accounts[i]
+	}
+	return byID, nil
+}
+
+// Loader helper: fetch products by ID, unknown and deleted ones load as nil
+func (s *Server) fetchProducts(ctx context.Context, ids []string) (map[string]*catalog.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	products, err := s.catalogClient.GetProducts(ctx, 0, 0, ids, "")
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*catalog.Product, len(products))
+	for i := range products {
+		if !products[i].Deleted {
+			byID[products[i].ID] = &products[i]
+		}
+	}
+	return byID, nil
+}
@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
-	// "github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/vektah/gqlparser/v2/ast"
 
-	// "github.com/gorilla/websocket"
+	"github.com/gorilla/websocket"
+	"github.com/pawan-sharma-12/go_microservices/auth"
+	"github.com/pawan-sharma-12/go_microservices/graceful"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 	"github.com/joho/godotenv"
 )
 
@@ -17,6 +26,7 @@ type AppConfig struct {
 	AccountURL string `envconfig:"ACCOUNT_SERVICE_URL"`
 	CatalogURL string `envconfig:"CATALOG_SERVICE_URL"`
 	OrderURL   string `envconfig:"ORDER_SERVICE_URL"`
+	AuthIssuer string `envconfig:"AUTH_ISSUER"`
 }
 
 func main() {
@@ -34,11 +44,20 @@ func main() {
 		log.Printf("📦 Loaded environment config from %s", envFile)
 	}
 
+	flushSpans, err := tracing.Setup(context.Background(), auth.ServiceGateway)
+	if err != nil {
+		log.Fatalf("Could not set up tracing: %v", err)
+	}
+
 	// Load config from environment
 	cfg := AppConfig{
 		AccountURL: os.Getenv("ACCOUNT_SERVICE_URL"),
 		CatalogURL: os.Getenv("CATALOG_SERVICE_URL"),
 		OrderURL:   os.Getenv("ORDER_SERVICE_URL"),
+		AuthIssuer: os.Getenv("AUTH_ISSUER"),
+	}
+	if cfg.AuthIssuer == "" {
+		cfg.AuthIssuer = "go_microservices/account"
 	}
 	log.Println("URL print : ",cfg.AccountURL, cfg.CatalogURL, cfg.OrderURL)
 	// Validate
@@ -46,19 +65,67 @@ func main() {
 		log.Fatalf("❌ Missing required environment variables: ACCOUNT_SERVICE_URL, CATALOG_SERVICE_URL, ORDER_SERVICE_URL")
 	}
 
+	authn, err := auth.NewAuthenticatorFromEnv(auth.ServiceGateway)
+	if err != nil {
+		log.Fatalf("❌ Invalid service auth config: %v", err)
+	}
+	if err := graceful.TimeoutFromEnv(); err != nil {
+		log.Fatalf("❌ Invalid shutdown timeout: %v", err)
+	}
+
 	// Create GraphQL server
-	server, err := NewGraphQlServer(cfg.AccountURL, cfg.CatalogURL, cfg.OrderURL)
+	server, err := NewGraphQlServer(cfg.AccountURL, cfg.CatalogURL, cfg.OrderURL, cfg.AuthIssuer, authn)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Create gqlgen handler with introspection enabled
-	srv := handler.NewDefaultServer(server.ToExecutableSchema())
+	srv := handler.New(server.ToExecutableSchema())
+	// Subscriptions run over websockets (graphql-ws). Pings keep idle
+	// connections open through proxies and detect clients that went away.
+	// Browsers cannot set headers on websockets, so the token may come in
+	// the connection_init payload instead.
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              server.websocketInit,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
+	srv.Use(Tracer{})
+	srv.SetErrorPresenter(errorPresenter)
 
 	// HTTP handlers
-	http.Handle("/graphql", srv)
-	http.Handle("/playground", playground.Handler("GraphQL Playground", "/graphql"))
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", server.AuthMiddleware(server.LoadersMiddleware(srv)))
+	mux.Handle("/playground", playground.Handler("GraphQL Playground", "/graphql"))
+	mux.HandleFunc("/livez", livez)
+	mux.Handle("/readyz", server.ReadyzHandler())
 
+	ctx, stop := graceful.SignalContext()
+	defer stop()
+
+	lis, err := net.Listen("tcp", ":8000")
+	if err != nil {
+		log.Fatal(err)
+	}
 	log.Println("GraphQL server running on :8000")
-	log.Fatal(http.ListenAndServe(":8000", nil))
+	err = graceful.ServeHTTP(ctx, &http.Server{Handler: traceHTTP(mux)}, lis)
+
+	// Requests have drained: close the connections to the services
+	server.Close()
+	flushSpans()
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("👋 GraphQL server stopped")
 }
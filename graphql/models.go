@@ -1,17 +1,59 @@
 package main
 
-// Account type is auto-generated in models_gen.go
-// The orders field is resolved via accountResolver.Orders() method
-// type Order struct{
-// 	ID 	  string   `json:"id"`
-// 	CreatedAt string   `json:"createAt"`
-// 	TotalPrice float64  `json:"totalPrice"`
-// 	Products []OrderedProduct `json:"products"`
-// }
-// type OrderedProduct struct{
-// 	ID 	  string `json:"id"`
-// 	Name  string `json:"name"`
-// 	Price float64 `json:"price"`
-// 	Quantity int    `json:"quantity"`
-// 	Description string `json:"description"`
-// }
\ No newline at end of file
+import (
+	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/money"
+)
+
+// The types below are bound in gqlgen.yaml instead of being generated, so
+// that ID fields keep the plain service IDs the resolvers work with while the
+// GraphQL id fields return global IDs.
+
+type Account struct {
+	ID     string        `json:"id"`
+	Name   string        `json:"name"`
+	Status AccountStatus `json:"status"`
+}
+
+func (Account) IsNode()            {}
+func (a Account) GetID() string    { return a.GlobalID() }
+func (a Account) GlobalID() string { return toGlobalID(accountType, a.ID) }
+
+type Product struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Price       *money.Money `json:"price"`
+	Description string       `json:"description"`
+	// Stock is nil for products whose stock is not tracked
+	Stock *int `json:"stock"`
+}
+
+func (Product) IsNode()            {}
+func (p Product) GetID() string    { return p.GlobalID() }
+func (p Product) GlobalID() string { return toGlobalID(productType, p.ID) }
+
+type Order struct {
+	ID         string          `json:"id"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	AccountID  string          `json:"accountId"`
+	TotalPrice *money.Money    `json:"totalPrice"`
+	Status     OrderStatus     `json:"status"`
+	Products   []*OrderProduct `json:"products"`
+}
+
+func (Order) IsNode()                   {}
+func (o Order) GetID() string           { return o.GlobalID() }
+func (o Order) GlobalID() string        { return toGlobalID(orderType, o.ID) }
+func (o Order) GlobalAccountID() string { return toGlobalID(accountType, o.AccountID) }
+
+// OrderProduct is a line of an order; its ID is the ordered product's
+type OrderProduct struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       *money.Money `json:"price"`
+	Quantity    int          `json:"quantity"`
+}
+
+func (p OrderProduct) GlobalID() string { return toGlobalID(productType, p.ID) }
@@ -3,40 +3,66 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/money"
 )
 
-type Account struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+type Node interface {
+	IsNode()
+	GetID() string
+}
+
+type AccountConnection struct {
+	Edges    []*AccountEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type AccountEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Account `json:"node"`
 }
 
 type AccountInput struct {
 	Name string `json:"name"`
 }
 
+type AuthPayload struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	Account      *Account  `json:"account"`
+}
+
 type Mutation struct {
 }
 
-type Order struct {
-	ID         string          `json:"id"`
-	CreatedAt  time.Time       `json:"createdAt"`
-	AccountID  string          `json:"accountId"`
-	TotalPrice float64         `json:"totalPrice"`
-	Products   []*OrderProduct `json:"products"`
+type OrderConnection struct {
+	Edges    []*OrderEdge `json:"edges"`
+	PageInfo *PageInfo    `json:"pageInfo"`
 }
 
-type OrderInput struct {
-	AccountID string               `json:"accountId"`
-	Products  []*OrderProductInput `json:"products"`
+type OrderEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Order `json:"node"`
 }
 
-type OrderProduct struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
+type OrderFilterInput struct {
+	CreatedAfter  *time.Time    `json:"createdAfter,omitempty"`
+	CreatedBefore *time.Time    `json:"createdBefore,omitempty"`
+	Statuses      []OrderStatus `json:"statuses,omitempty"`
+	MinTotal      *money.Money  `json:"minTotal,omitempty"`
+	MaxTotal      *money.Money  `json:"maxTotal,omitempty"`
+}
+
+type OrderInput struct {
+	AccountID      string               `json:"accountId"`
+	Products       []*OrderProductInput `json:"products"`
+	IdempotencyKey *string              `json:"idempotencyKey,omitempty"`
 }
 
 type OrderProductInput struct {
@@ -44,23 +70,282 @@ type OrderProductInput struct {
 	Quantity int    `json:"quantity"`
 }
 
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
 type PaginationInput struct {
 	Skip int `json:"skip"`
 	Take int `json:"take"`
 }
 
-type Product struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Description string  `json:"description"`
+type ProductConnection struct {
+	Edges    []*ProductEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type ProductEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Product `json:"node"`
 }
 
 type ProductInput struct {
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Description string  `json:"description"`
+	Name        string       `json:"name"`
+	Price       *money.Money `json:"price"`
+	Description string       `json:"description"`
+	Stock       *int         `json:"stock,omitempty"`
+}
+
+type ProductUpdateInput struct {
+	Name        *string      `json:"name,omitempty"`
+	Price       *money.Money `json:"price,omitempty"`
+	Description *string      `json:"description,omitempty"`
+	Stock       *int         `json:"stock,omitempty"`
 }
 
 type Query struct {
 }
+
+type RegisterInput struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type Subscription struct {
+}
+
+type AccountStatus string
+
+const (
+	AccountStatusActive      AccountStatus = "ACTIVE"
+	AccountStatusDeactivated AccountStatus = "DEACTIVATED"
+)
+
+var AllAccountStatus = []AccountStatus{
+	AccountStatusActive,
+	AccountStatusDeactivated,
+}
+
+func (e AccountStatus) IsValid() bool {
+	switch e {
+	case AccountStatusActive, AccountStatusDeactivated:
+		return true
+	}
+	return false
+}
+
+func (e AccountStatus) String() string {
+	return string(e)
+}
+
+func (e *AccountStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AccountStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AccountStatus", str)
+	}
+	return nil
+}
+
+func (e AccountStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *AccountStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e AccountStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type OrderSort string
+
+const (
+	OrderSortCreatedAtDesc OrderSort = "CREATED_AT_DESC"
+	OrderSortCreatedAtAsc  OrderSort = "CREATED_AT_ASC"
+	OrderSortTotalDesc     OrderSort = "TOTAL_DESC"
+	OrderSortTotalAsc      OrderSort = "TOTAL_ASC"
+)
+
+var AllOrderSort = []OrderSort{
+	OrderSortCreatedAtDesc,
+	OrderSortCreatedAtAsc,
+	OrderSortTotalDesc,
+	OrderSortTotalAsc,
+}
+
+func (e OrderSort) IsValid() bool {
+	switch e {
+	case OrderSortCreatedAtDesc, OrderSortCreatedAtAsc, OrderSortTotalDesc, OrderSortTotalAsc:
+		return true
+	}
+	return false
+}
+
+func (e OrderSort) String() string {
+	return string(e)
+}
+
+func (e *OrderSort) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderSort(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderSort", str)
+	}
+	return nil
+}
+
+func (e OrderSort) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OrderSort) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OrderSort) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "PENDING"
+	OrderStatusPaid      OrderStatus = "PAID"
+	OrderStatusFulfilled OrderStatus = "FULFILLED"
+	OrderStatusShipped   OrderStatus = "SHIPPED"
+	OrderStatusDelivered OrderStatus = "DELIVERED"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+)
+
+var AllOrderStatus = []OrderStatus{
+	OrderStatusPending,
+	OrderStatusPaid,
+	OrderStatusFulfilled,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+}
+
+func (e OrderStatus) IsValid() bool {
+	switch e {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusFulfilled, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e OrderStatus) String() string {
+	return string(e)
+}
+
+func (e *OrderStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderStatus", str)
+	}
+	return nil
+}
+
+func (e OrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *OrderStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e OrderStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type Role string
+
+const (
+	RoleUser  Role = "USER"
+	RoleOwner Role = "OWNER"
+	RoleAdmin Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleUser,
+	RoleOwner,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleUser, RoleOwner, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *Role) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e Role) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
@@ -9,8 +9,12 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
+	"github.com/pawan-sharma-12/go_microservices/account"
+	"github.com/pawan-sharma-12/go_microservices/catalog"
 	"github.com/pawan-sharma-12/go_microservices/order"
 )
 
@@ -29,27 +33,126 @@ func (r *mutationResolver) CreateAccount(ctx context.Context, in AccountInput) (
 		log.Println(err)
 		return  nil, err
 	}
-	return &Account{
-		ID:a.ID,
-		Name: a.Name,
-	}, nil
+	return toGraphQLAccount(a), nil
+}
+
+func (r *mutationResolver) UpdateAccount(ctx context.Context, id string, in AccountInput) (*Account, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	id, err := parseID(accountType, id)
+	if err != nil {
+		return nil, err
+	}
+	a, err := r.server.accountClient.UpdateAccount(ctx, id, in.Name)
+	if err != nil{
+		log.Println(err)
+		return nil, err
+	}
+	return toGraphQLAccount(a), nil
+}
+
+func (r *mutationResolver) DeactivateAccount(ctx context.Context, id string) (*Account, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	id, err := parseID(accountType, id)
+	if err != nil {
+		return nil, err
+	}
+	a, err := r.server.accountClient.DeactivateAccount(ctx, id)
+	if err != nil{
+		log.Println(err)
+		return nil, err
+	}
+	return toGraphQLAccount(a), nil
+}
+
+func (r *mutationResolver) ReactivateAccount(ctx context.Context, id string) (*Account, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	id, err := parseID(accountType, id)
+	if err != nil {
+		return nil, err
+	}
+	a, err := r.server.accountClient.ReactivateAccount(ctx, id)
+	if err != nil{
+		log.Println(err)
+		return nil, err
+	}
+	return toGraphQLAccount(a), nil
+}
+
+func (r *mutationResolver) DeleteAccount(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	id, err := parseID(accountType, id)
+	if err != nil {
+		return false, err
+	}
+	if err := r.server.accountClient.DeleteAccount(ctx, id); err != nil{
+		log.Println(err)
+		return false, err
+	}
+	return true, nil
 }
 
 func (r *mutationResolver) CreateProduct(ctx context.Context, in ProductInput) (*Product, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
-	p, err := r.server.catalogClient.PostProduct(ctx, in.Name, in.Description, in.Price)
+	var stock uint64
+	if in.Stock != nil {
+		if *in.Stock < 0 {
+			return nil, ErrInvalidParameter
+		}
+		stock = uint64(*in.Stock)
+	}
+	p, err := r.server.catalogClient.PostProduct(ctx, in.Name, in.Description, *in.Price, stock)
 	if err != nil{
 		log.Println(err)
 		return  nil, err
 	}
-	return &Product{
-		ID: p.ID,
-		Name : p.Name,
-		Description: p.Description,
-		Price : p.Price,
-	}, nil
+	return toGraphQLProduct(p), nil
+}
+func (r *mutationResolver) UpdateProduct(ctx context.Context, id string, in ProductUpdateInput) (*Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	id, err := parseID(productType, id)
+	if err != nil {
+		return nil, err
+	}
+	update := catalog.ProductUpdate{
+		Name: in.Name,
+		Description: in.Description,
+		Price: in.Price,
+	}
+	if in.Stock != nil {
+		if *in.Stock < 0 {
+			return nil, ErrInvalidParameter
+		}
+		stock := uint64(*in.Stock)
+		update.Stock = &stock
+	}
+	p, err := r.server.catalogClient.UpdateProduct(ctx, id, update)
+	if err != nil{
+		log.Println(err)
+		return nil, err
+	}
+	return toGraphQLProduct(p), nil
+}
+
+func (r *mutationResolver) DeleteProduct(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	id, err := parseID(productType, id)
+	if err != nil {
+		return false, err
+	}
+	if err := r.server.catalogClient.DeleteProduct(ctx, id); err != nil{
+		log.Println(err)
+		return false, err
+	}
+	return true, nil
 }
+
 func (r *mutationResolver) CreateOrder(ctx context.Context, in OrderInput) (*Order, error) {
 	log.Printf("📝 CreateOrder called with AccountID: %s, Products: %+v", in.AccountID, in.Products)
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
@@ -64,19 +167,118 @@ func (r *mutationResolver) CreateOrder(ctx context.Context, in OrderInput) (*Ord
 		if p.Quantity < 0 {
 			return nil, ErrInvalidParameter
 		}
+		productID, err := parseID(productType, p.ID)
+		if err != nil {
+			return nil, err
+		}
 		products = append(products, order.OrderProduct{
-			ID : p.ID, 
+			ID : productID, 
 			Quantity : uint64(p.Quantity),
 		})
 	}
-	o, err := r.server.orderClient.PostOrder(ctx, in.AccountID, products)
+	products = order.MergeProducts(products)
+	if len(products) == 0 {
+		return nil, ErrInvalidParameter
+	}
+
+	// Reject unknown products before calling the order service
+	productIDs := make([]string, 0, len(products))
+	for _, p := range products {
+		productIDs = append(productIDs, p.ID)
+	}
+	catalogProducts, err := r.server.catalogClient.GetProducts(ctx, 0, 0, productIDs, "")
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	if missing := order.MissingProductIDs(products, catalogProducts); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: unknown product IDs: %s", ErrInvalidParameter, strings.Join(missing, ", "))
+	}
+
+	idempotencyKey := ""
+	if in.IdempotencyKey != nil {
+		idempotencyKey = *in.IdempotencyKey
+	}
+	accountID, err := parseID(accountType, in.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	o, err := r.server.orderClient.PostOrder(ctx, accountID, products, idempotencyKey)
+	if err != nil{
+		log.Println(err)
+		return nil, err
+	}
+	return toGraphQLOrder(o), nil
+}
+
+func (r *mutationResolver) UpdateOrderStatus(ctx context.Context, id string, status OrderStatus) (*Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	id, err := parseID(orderType, id)
+	if err != nil {
+		return nil, err
+	}
+	o, err := r.server.orderClient.UpdateOrderStatus(ctx, id, order.OrderStatus(strings.ToLower(string(status))))
+	if err != nil{
+		log.Println(err)
+		return nil, err
+	}
+	return toGraphQLOrder(o), nil
+}
+
+func (r *mutationResolver) CancelOrder(ctx context.Context, id string) (*Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	id, err := parseID(orderType, id)
+	if err != nil {
+		return nil, err
+	}
+	o, err := r.server.orderClient.CancelOrder(ctx, id)
+	if err != nil{
+		log.Println(err)
+		return nil, err
+	}
+	return toGraphQLOrder(o), nil
+}
+func (r *mutationResolver) Register(ctx context.Context, in RegisterInput) (*AuthPayload, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	a, tokens, err := r.server.accountClient.Register(ctx, in.Name, in.Email, in.Password)
+	if err != nil{
+		log.Println(err)
+		return nil, err
+	}
+	return toAuthPayload(a, tokens), nil
+}
+
+func (r *mutationResolver) Login(ctx context.Context, email string, password string) (*AuthPayload, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	a, tokens, err := r.server.accountClient.Login(ctx, email, password)
+	if err != nil{
+		log.Println(err)
+		return nil, err
+	}
+	return toAuthPayload(a, tokens), nil
+}
+
+func (r *mutationResolver) RefreshToken(ctx context.Context, refreshToken string) (*AuthPayload, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	a, tokens, err := r.server.accountClient.RefreshToken(ctx, refreshToken)
 	if err != nil{
 		log.Println(err)
 		return nil, err
 	}
-	return &Order{
-		ID : o.ID,
-		CreatedAt: o.CreatedAt,
-		TotalPrice: o.TotalPrice,
-	},nil
-}
\ No newline at end of file
+	return toAuthPayload(a, tokens), nil
+}
+
+// Account helper: convert a login result to its GraphQL payload
+func toAuthPayload(a *account.Account, tokens *account.TokenPair) *AuthPayload {
+	return &AuthPayload{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt,
+		Account:      toGraphQLAccount(a),
+	}
+}
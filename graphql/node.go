@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Global IDs let node(id) find any entity from its ID alone. They are the
+// base64 of the type name and the service ID, e.g. "Account:<ksuid>".
+// Arguments take them as well as plain service IDs.
+const (
+	accountType = "Account"
+	productType = "Product"
+	orderType   = "Order"
+)
+
+func toGlobalID(typ string, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(typ + ":" + id))
+}
+
+// fromGlobalID splits a global ID into type and service ID. ok is false if
+// id is not a global ID.
+func fromGlobalID(id string) (typ string, serviceID string, ok bool) {
+	b, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return "", "", false
+	}
+	typ, serviceID, ok = strings.Cut(string(b), ":")
+	switch {
+	case !ok:
+		return "", "", false
+	case typ == accountType, typ == productType, typ == orderType:
+		return typ, serviceID, true
+	default:
+		return "", "", false
+	}
+}
+
+// parseID returns the service ID of an argument naming an entity of type
+// typ, by global or plain ID
+func parseID(typ string, id string) (string, error) {
+	if t, serviceID, ok := fromGlobalID(id); ok {
+		if t != typ {
+			return "", fmt.Errorf("%w: %q is not the ID of a %s", ErrInvalidParameter, id, typ)
+		}
+		return serviceID, nil
+	}
+	return id, nil
+}
+
+// Node resolver: refetch any entity by global ID. Unknown entities are null.
+func (r *queryResolver) Node(ctx context.Context, id string) (Node, error) {
+	typ, serviceID, ok := fromGlobalID(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a global ID", ErrInvalidParameter, id)
+	}
+
+	switch typ {
+	case accountType:
+		a, err := r.Account(ctx, id)
+		if a == nil || err != nil {
+			return nil, err
+		}
+		return a, nil
+	case productType:
+		p, err := r.Product(ctx, id)
+		if p == nil || err != nil {
+			return nil, err
+		}
+		return p, nil
+	default:
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		o, err := r.server.orderClient.GetOrder(ctx, serviceID)
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		if err != nil {
+			log.Println("Error resolving node query:", err)
+			return nil, err
+		}
+		if err := authorizeAccount(ctx, o.AccountID); err != nil {
+			return nil, err
+		}
+		return toGraphQLOrder(o), nil
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseID(t *testing.T) {
+	const id = "2qJ1Vh4QZb3kEoCqG2zT7YkJ9aB"
+	tests := []struct {
+		name    string
+		typ     string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"global", accountType, toGlobalID(accountType, id), id, false},
+		{"plain", productType, id, id, false},
+		{"other type", orderType, toGlobalID(productType, id), "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseID(tt.typ, tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("%s: got %q, %v, want ErrInvalidParameter", tt.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, _, ok := fromGlobalID(id); ok {
+		t.Errorf("plain ID %q decoded as a global ID", id)
+	}
+}
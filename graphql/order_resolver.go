@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log"
+)
+
+type orderResolver struct {
+	server *Server
+}
+
+// Account resolves the account that placed the order. Accounts of all
+// orders in a response are fetched together.
+func (r *orderResolver) Account(ctx context.Context, obj *Order) (*Account, error) {
+	a, err := r.server.loaders(ctx).Accounts.Load(ctx, obj.AccountID)
+	if err != nil {
+		log.Println("Error resolving Account in order_resolver  : ", err)
+		return nil, err
+	}
+	if a == nil {
+		return nil, nil
+	}
+	return toGraphQLAccount(a), nil
+}
+
+type orderProductResolver struct {
+	server *Server
+}
+
+// Product resolves the catalog product of an order line. Products of all
+// lines in a response are fetched together.
+func (r *orderProductResolver) Product(ctx context.Context, obj *OrderProduct) (*Product, error) {
+	p, err := r.server.loaders(ctx).Products.Load(ctx, obj.ID)
+	if err != nil {
+		log.Println("Error resolving Product in order_resolver  : ", err)
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+	return toGraphQLProduct(p), nil
+}
@@ -2,8 +2,16 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strings"
 	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/account"
+	"github.com/pawan-sharma-12/go_microservices/catalog"
+	"github.com/pawan-sharma-12/go_microservices/order"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type queryResolver struct {
@@ -16,15 +24,14 @@ func (r *queryResolver) Accounts(ctx context.Context, pagination PaginationInput
 	defer cancel()
 
 	if id != nil {
-		a, err := r.server.accountClient.GetAccount(ctx, *id)
+		a, err := r.Account(ctx, *id)
 		if err != nil {
-			log.Println("Error resolving account query:", err)
 			return nil, err
 		}
-		return []*Account{{
-			ID:   a.ID,
-			Name: a.Name,
-		}}, nil
+		if a == nil {
+			return nil, status.Errorf(codes.NotFound, "account %q not found", *id)
+		}
+		return []*Account{a}, nil
 	}
 
 	// Use pagination directly; no nil check needed
@@ -38,10 +45,7 @@ func (r *queryResolver) Accounts(ctx context.Context, pagination PaginationInput
 
 	accounts := make([]*Account, 0, len(accountList))
 	for _, a := range accountList {
-		accounts = append(accounts, &Account{
-			ID:   a.ID,
-			Name: a.Name,
-		})
+		accounts = append(accounts, toGraphQLAccount(&a))
 	}
 
 	return accounts, nil
@@ -53,17 +57,14 @@ func (r *queryResolver) Products(ctx context.Context, pagination PaginationInput
 	defer cancel()
 
 	if id != nil {
-		p, err := r.server.catalogClient.GetProduct(ctx, *id)
+		p, err := r.Product(ctx, *id)
 		if err != nil {
-			log.Println("Error resolving product query:", err)
 			return nil, err
 		}
-		return []*Product{{
-			ID:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-			Price:       p.Price,
-		}}, nil
+		if p == nil {
+			return nil, status.Errorf(codes.NotFound, "product %q not found", *id)
+		}
+		return []*Product{p}, nil
 	}
 
 	skip, take := pagination.bounds()
@@ -81,18 +82,250 @@ func (r *queryResolver) Products(ctx context.Context, pagination PaginationInput
 
 	products := make([]*Product, 0, len(productList))
 	for _, p := range productList {
-		products = append(products, &Product{
+		products = append(products, toGraphQLProduct(&p))
+	}
+
+	return products, nil
+}
+
+// AccountsConnection resolver
+func (r *queryResolver) AccountsConnection(ctx context.Context, first *int, after *string) (*AccountConnection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	size, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	page, err := r.server.accountClient.GetAccountsPage(ctx, derefString(after), size)
+	if err != nil {
+		log.Println("Error fetching accounts page:", err)
+		return nil, err
+	}
+
+	edges := make([]*AccountEdge, 0, len(page.Accounts))
+	for i := range page.Accounts {
+		edges = append(edges, &AccountEdge{
+			Cursor: page.Cursors[i],
+			Node:   toGraphQLAccount(&page.Accounts[i]),
+		})
+	}
+	return &AccountConnection{
+		Edges:    edges,
+		PageInfo: toPageInfo(page.Cursors, page.HasNextPage),
+	}, nil
+}
+
+// ProductsConnection resolver
+func (r *queryResolver) ProductsConnection(ctx context.Context, first *int, after *string, query *string) (*ProductConnection, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	size, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+	page, err := r.server.catalogClient.GetProductsPage(ctx, derefString(query), derefString(after), size)
+	if err != nil {
+		log.Println("Error fetching products page:", err)
+		return nil, err
+	}
+
+	edges := make([]*ProductEdge, 0, len(page.Products))
+	for i := range page.Products {
+		edges = append(edges, &ProductEdge{
+			Cursor: page.Cursors[i],
+			Node:   toGraphQLProduct(&page.Products[i]),
+		})
+	}
+	return &ProductConnection{
+		Edges:    edges,
+		PageInfo: toPageInfo(page.Cursors, page.HasNextPage),
+	}, nil
+}
+
+// Account resolver: a single account by ID, null if it does not exist
+func (r *queryResolver) Account(ctx context.Context, id string) (*Account, error) {
+	accountID, err := parseID(accountType, id)
+	if err != nil {
+		return nil, err
+	}
+	a, err := r.server.loaders(ctx).Accounts.Load(ctx, accountID)
+	if err != nil {
+		log.Println("Error resolving account query:", err)
+		return nil, err
+	}
+	if a == nil {
+		return nil, nil
+	}
+	return toGraphQLAccount(a), nil
+}
+
+// Product resolver: a single product by ID, null if it does not exist
+func (r *queryResolver) Product(ctx context.Context, id string) (*Product, error) {
+	productID, err := parseID(productType, id)
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.server.loaders(ctx).Products.Load(ctx, productID)
+	if err != nil {
+		log.Println("Error resolving product query:", err)
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+	return toGraphQLProduct(p), nil
+}
+
+// Order resolver: a single order by ID, null if it does not exist
+func (r *queryResolver) Order(ctx context.Context, id string) (*Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	orderID, err := parseID(orderType, id)
+	if err != nil {
+		return nil, err
+	}
+	o, err := r.server.orderClient.GetOrder(ctx, orderID)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println("Error resolving order query:", err)
+		return nil, err
+	}
+
+	return toGraphQLOrder(o), nil
+}
+
+// Orders resolver: orders of all accounts or of one
+func (r *queryResolver) Orders(ctx context.Context, accountID *string, filter *OrderFilterInput, sort *OrderSort, first *int, after *string) ([]*Order, error) {
+	id, err := parseID(accountType, derefString(accountID))
+	if err != nil {
+		return nil, err
+	}
+	page, err := r.server.ordersPage(ctx, id, filter, sort, first, after)
+	if err != nil {
+		log.Println("Error resolving orders query:", err)
+		return nil, err
+	}
+
+	orders := make([]*Order, 0, len(page.Orders))
+	for i := range page.Orders {
+		orders = append(orders, toGraphQLOrder(&page.Orders[i]))
+	}
+	return orders, nil
+}
+
+// OrdersConnection resolver
+func (r *queryResolver) OrdersConnection(ctx context.Context, accountID *string, filter *OrderFilterInput, sort *OrderSort, first *int, after *string) (*OrderConnection, error) {
+	id, err := parseID(accountType, derefString(accountID))
+	if err != nil {
+		return nil, err
+	}
+	page, err := r.server.ordersPage(ctx, id, filter, sort, first, after)
+	if err != nil {
+		log.Println("Error resolving ordersConnection query:", err)
+		return nil, err
+	}
+
+	edges := make([]*OrderEdge, 0, len(page.Orders))
+	for i := range page.Orders {
+		edges = append(edges, &OrderEdge{
+			Cursor: page.Cursors[i],
+			Node:   toGraphQLOrder(&page.Orders[i]),
+		})
+	}
+	return &OrderConnection{
+		Edges:    edges,
+		PageInfo: toPageInfo(page.Cursors, page.HasNextPage),
+	}, nil
+}
+
+// Account helper: convert an account service Account to the GraphQL model
+func toGraphQLAccount(a *account.Account) *Account {
+	return &Account{
+		ID:     a.ID,
+		Name:   a.Name,
+		Status: AccountStatus(strings.ToUpper(string(a.Status))),
+	}
+}
+
+// Product helper: convert a catalog Product to the GraphQL model. Untracked
+// stock is null.
+func toGraphQLProduct(p *catalog.Product) *Product {
+	product := &Product{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       &p.Price,
+	}
+	if !p.StockUntracked {
+		stock := int(p.Stock)
+		product.Stock = &stock
+	}
+	return product
+}
+
+// Order helper: convert an order service Order to the GraphQL model
+func toGraphQLOrder(o *order.Order) *Order {
+	products := make([]*OrderProduct, 0, len(o.Products))
+	for _, p := range o.Products {
+		products = append(products, &OrderProduct{
 			ID:          p.ID,
 			Name:        p.Name,
 			Description: p.Description,
-			Price:       p.Price,
+			Price:       &p.Price,
+			Quantity:    int(p.Quantity),
 		})
 	}
 
-	return products, nil
+	return &Order{
+		ID:         o.ID,
+		CreatedAt:  o.CreatedAt,
+		AccountID:  o.AccountID,
+		TotalPrice: &o.TotalPrice,
+		Status:     OrderStatus(strings.ToUpper(string(o.Status))),
+		Products:   products,
+	}
 }
 
 // Pagination helper (no nil check needed because PaginationInput is a value)
 func (p PaginationInput) bounds() (uint64, uint64) {
 	return uint64(p.Skip), uint64(p.Take)
 }
+
+// Connection helpers: default and maximum page size of cursor pagination
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+func pageSize(first *int) (uint64, error) {
+	if first == nil {
+		return defaultPageSize, nil
+	}
+	if *first < 0 {
+		return 0, fmt.Errorf("%w: first must not be negative", ErrInvalidParameter)
+	}
+	if *first == 0 || *first > maxPageSize {
+		return maxPageSize, nil
+	}
+	return uint64(*first), nil
+}
+
+func toPageInfo(cursors []string, hasNextPage bool) *PageInfo {
+	info := &PageInfo{HasNextPage: hasNextPage}
+	if len(cursors) > 0 {
+		info.EndCursor = &cursors[len(cursors)-1]
+	}
+	return info
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/pawan-sharma-12/go_microservices/order"
+)
+
+type subscriptionResolver struct {
+	server *Server
+}
+
+// OrderUpdated streams the changes of one order. gqlgen cancels ctx when the
+// client unsubscribes or disconnects, which ends the order service stream.
+func (r *subscriptionResolver) OrderUpdated(ctx context.Context, orderID string) (<-chan *Order, error) {
+	id, err := parseID(orderType, orderID)
+	if err != nil {
+		return nil, err
+	}
+	o, err := r.server.orderClient.GetOrder(ctx, id)
+	if err != nil {
+		log.Println("Error subscribing to orderUpdated:", err)
+		return nil, err
+	}
+	if err := authorizeAccount(ctx, o.AccountID); err != nil {
+		return nil, err
+	}
+	events, err := r.server.orderClient.WatchOrders(ctx, id, "")
+	if err != nil {
+		log.Println("Error subscribing to orderUpdated:", err)
+		return nil, err
+	}
+	return forwardOrders(ctx, events), nil
+}
+
+// OrdersForAccount streams the orders of an account as they are created or
+// change status
+func (r *subscriptionResolver) OrdersForAccount(ctx context.Context, accountID string) (<-chan *Order, error) {
+	id, err := parseID(accountType, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeAccount(ctx, id); err != nil {
+		return nil, err
+	}
+	events, err := r.server.orderClient.WatchOrders(ctx, "", id)
+	if err != nil {
+		log.Println("Error subscribing to ordersForAccount:", err)
+		return nil, err
+	}
+	return forwardOrders(ctx, events), nil
+}
+
+// Subscription helper: convert order events to GraphQL orders until either
+// side goes away. Closing the channel completes the subscription.
+func forwardOrders(ctx context.Context, events <-chan order.OrderEvent) <-chan *Order {
+	orders := make(chan *Order)
+	go func() {
+		defer close(orders)
+		for e := range events {
+			select {
+			case orders <- toGraphQLOrder(&e.Order):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return orders
+}
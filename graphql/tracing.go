@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/pawan-sharma-12/go_microservices/graphql"
+
+// Tracer records a span for each GraphQL operation, named after its type
+// and name, e.g. "mutation createOrder", and one for each resolver under
+// it, e.g. "Order.products". Fields read straight off a model get none.
+type Tracer struct{}
+
+var (
+	_ graphql.HandlerExtension    = Tracer{}
+	_ graphql.ResponseInterceptor = Tracer{}
+	_ graphql.FieldInterceptor    = Tracer{}
+)
+
+func (Tracer) ExtensionName() string {
+	return "Tracer"
+}
+
+func (Tracer) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	ctx, span := otel.Tracer(tracerName).Start(ctx, operationName(oc),
+		trace.WithAttributes(attribute.String("graphql.operation.name", oc.OperationName)),
+	)
+	defer span.End()
+
+	res := next(ctx)
+	if res != nil && len(res.Errors) > 0 {
+		span.SetStatus(codes.Error, res.Errors.Error())
+	}
+	return res
+}
+
+func (Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, fc.Object+"."+fc.Field.Name,
+		trace.WithAttributes(attribute.String("graphql.field.path", fc.Path().String())),
+	)
+	defer span.End()
+
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
+
+// Helper: "query", "mutation" or "subscription" followed by the operation
+// name, or by its first field for anonymous operations
+func operationName(oc *graphql.OperationContext) string {
+	if oc.Operation == nil {
+		return "graphql"
+	}
+	name := oc.Operation.Name
+	if name == "" && len(oc.Operation.SelectionSet) > 0 {
+		if field, ok := oc.Operation.SelectionSet[0].(*ast.Field); ok {
+			name = field.Name
+		}
+	}
+	return string(oc.Operation.Operation) + " " + name
+}
+
+// traceHTTP continues the traces of incoming requests and records a span for
+// each. Probes are left out.
+func traceHTTP(h http.Handler) http.Handler {
+	return otelhttp.NewHandler(h, "graphql",
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return r.URL.Path != "/livez" && r.URL.Path != "/readyz"
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestTracer(t *testing.T) {
+	recorder, stop := tracing.NewRecorder()
+	defer stop()
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{
+			Operation:    ast.Mutation,
+			SelectionSet: ast.SelectionSet{&ast.Field{Name: "createOrder"}},
+		},
+	})
+	field := func(ctx context.Context, object, name string, isResolver bool, err error) {
+		ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Object:     object,
+			Field:      graphql.CollectedField{Field: &ast.Field{Name: name, Alias: name}},
+			IsResolver: isResolver,
+		})
+		Tracer{}.InterceptField(ctx, func(context.Context) (any, error) { return nil, err })
+	}
+	Tracer{}.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		field(ctx, "Mutation", "createOrder", true, nil)
+		field(ctx, "Order", "id", false, nil)
+		field(ctx, "Order", "products", true, errors.New("catalog unavailable"))
+		return &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("catalog unavailable")}}
+	})
+
+	spans := recorder.Ended()
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	want := []string{"Mutation.createOrder", "Order.products", "mutation createOrder"}
+	if len(names) != len(want) {
+		t.Fatalf("got spans %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("span %d: got %q, want %q", i, names[i], want[i])
+		}
+	}
+	operation := spans[2]
+	for _, s := range spans[:2] {
+		if s.Parent().SpanID() != operation.SpanContext().SpanID() {
+			t.Errorf("%s: not under the operation span", s.Name())
+		}
+	}
+	if spans[1].Status().Code != codes.Error || operation.Status().Code != codes.Error {
+		t.Errorf("errors not recorded: %v, %v", spans[1].Status(), operation.Status())
+	}
+}
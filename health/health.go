@@ -0,0 +1,168 @@
+// Package health serves the standard gRPC health checking protocol
+// (grpc.health.v1) with statuses computed from checks of a service's
+// dependencies, and asks other services for theirs.
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var (
+	// checkTimeout bounds each round of dependency checks
+	checkTimeout = 2 * time.Second
+	// pollInterval is how often the checks run in the background, so that
+	// Watch streams see changes without anyone calling Check
+	pollInterval = 10 * time.Second
+	// resultTTL is how long the result of the checks answers Check calls,
+	// so that frequent probes do not hammer the dependencies
+	resultTTL = time.Second
+)
+
+// Check reports whether a dependency is usable
+type Check func(ctx context.Context) error
+
+// Checks are the dependencies of a service by name
+type Checks map[string]Check
+
+// Server answers health checks of one service. It is serving while all its
+// checks pass; the status applies to the whole server ("") and to the gRPC
+// service name.
+type Server struct {
+	*grpchealth.Server
+	service string
+	checks  Checks
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	failure   string
+	reported  bool
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewServer runs checks for service until ctx is done or Stop is called.
+// From then on the server reports not serving, so that clients move away
+// while it drains.
+func NewServer(ctx context.Context, service string, checks Checks) *Server {
+	s := &Server{
+		Server:  grpchealth.NewServer(),
+		service: service,
+		checks:  checks,
+		stop:    make(chan struct{}),
+	}
+	s.update(context.Background(), true)
+	go s.poll(ctx)
+	return s
+}
+
+// Register serves s from srv
+func (s *Server) Register(srv *grpc.Server) {
+	healthpb.RegisterHealthServer(srv, s)
+}
+
+// Check runs the checks, unless they ran within resultTTL, before answering
+func (s *Server) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.update(ctx, false)
+	return s.Server.Check(ctx, req)
+}
+
+// Stop ends the background checks and reports the server as not serving
+// from then on
+func (s *Server) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+		s.Server.Shutdown()
+	})
+}
+
+func (s *Server) poll(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.Stop()
+			return
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.update(context.Background(), true)
+		}
+	}
+}
+
+// Helper: run the checks and set the statuses. Changes are logged.
+func (s *Server) update(ctx context.Context, force bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !force && time.Since(s.checkedAt) < resultTTL {
+		return
+	}
+	s.checkedAt = time.Now()
+
+	failure := s.run(ctx)
+	if failure != s.failure || !s.reported {
+		if failure == "" {
+			log.Printf("✅ %s is serving", s.service)
+		} else {
+			log.Printf("❌ %s is not serving: %s", s.service, failure)
+		}
+	}
+	s.failure, s.reported = failure, true
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if failure != "" {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.Server.SetServingStatus("", status)
+	s.Server.SetServingStatus(s.service, status)
+}
+
+// Helper: run all checks at once and describe those that failed
+func (s *Server) run(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures []string
+	)
+	for name, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := check(ctx); err != nil {
+				mu.Lock()
+				failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	sort.Strings(failures)
+	return strings.Join(failures, "; ")
+}
+
+// Probe asks the server behind conn whether service is serving
+func Probe(ctx context.Context, conn grpc.ClientConnInterface, service string) error {
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return err
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("%s is %s", service, res.Status)
+	}
+	return nil
+}
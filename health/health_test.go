@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestServer(t *testing.T) {
+	defer func(ttl time.Duration) { resultTTL = ttl }(resultTTL)
+	resultTTL = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var down atomic.Bool
+	s := NewServer(ctx, "test.Service", Checks{
+		"ok": func(context.Context) error { return nil },
+		"db": func(context.Context) error {
+			if down.Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	})
+
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	s.Register(srv)
+	go srv.Serve(lis)
+	defer srv.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for _, service := range []string{"", "test.Service"} {
+		if err := Probe(ctx, conn, service); err != nil {
+			t.Errorf("service %q with all checks passing: %v", service, err)
+		}
+	}
+	down.Store(true)
+	if err := Probe(ctx, conn, "test.Service"); err == nil {
+		t.Error("failing check reported as serving")
+	}
+	down.Store(false)
+	if err := Probe(ctx, conn, ""); err != nil {
+		t.Errorf("after recovery: %v", err)
+	}
+	if err := Probe(ctx, conn, "other.Service"); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown service: got %v, want NotFound", err)
+	}
+
+	cancel()
+	<-s.stop
+	if err := Probe(context.Background(), conn, ""); err == nil {
+		t.Error("stopped server reported as serving")
+	}
+}
@@ -0,0 +1,111 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const DefaultCurrency = "USD"
+
+var (
+	ErrInvalidCurrency  = errors.New("invalid currency code")
+	ErrNegativeAmount   = errors.New("amount must not be negative")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is an amount in the minor unit of its ISO 4217 currency,
+// e.g. cents for USD. It is never represented as a float.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// minorUnits lists currencies whose minor unit is not 1/100
+var minorUnits = map[string]int{
+	"BHD": 3,
+	"CLP": 0,
+	"IQD": 3,
+	"ISK": 0,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"TND": 3,
+	"VND": 0,
+}
+
+func New(amount int64, currency string) Money {
+	return Money{
+		Amount:   amount,
+		Currency: strings.ToUpper(currency),
+	}
+}
+
+// Exponent returns the number of decimal places of the currency's minor unit
+func Exponent(currency string) int {
+	if e, ok := minorUnits[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// FromFloat converts a legacy floating point amount in major units.
+// It exists only to migrate data stored before prices were integers.
+func FromFloat(f float64, currency string) Money {
+	scale := math.Pow10(Exponent(currency))
+	return New(int64(math.Round(f*scale)), currency)
+}
+
+// Validate checks that the currency looks like an ISO 4217 code and the
+// amount is not negative.
+func (m Money) Validate() error {
+	if len(m.Currency) != 3 {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, m.Currency)
+	}
+	for _, c := range m.Currency {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("%w: %q", ErrInvalidCurrency, m.Currency)
+		}
+	}
+	if m.Amount < 0 {
+		return ErrNegativeAmount
+	}
+	return nil
+}
+
+// Add sums two amounts of the same currency. The zero Money adds to
+// anything, so it can be used as the start of a running total.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency == "" {
+		return o, nil
+	}
+	if o.Currency == "" {
+		return m, nil
+	}
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Mul(n uint64) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+func (m Money) String() string {
+	e := Exponent(m.Currency)
+	if e == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+	scale := int64(math.Pow10(e))
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/scale, e, amount%scale, m.Currency)
+}
@@ -2,11 +2,16 @@ package order
 
 import (
 	"context"
+	"io"
 	"log"
 
+	"github.com/pawan-sharma-12/go_microservices/auth"
+	"github.com/pawan-sharma-12/go_microservices/health"
+	"github.com/pawan-sharma-12/go_microservices/money"
 	"github.com/pawan-sharma-12/go_microservices/order/pb"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Client struct {
@@ -14,13 +19,16 @@ type Client struct {
 	service pb.OrderServiceClient
 }
 
-func NewClient(url string) (*Client, error) {
+// NewClient connects to the order service. authn secures the connection and
+// signs the calls.
+func NewClient(url string, authn *auth.Authenticator) (*Client, error) {
 	log.Printf("🔗 Order client connecting to: %s", url)
 	// Force direct connection, bypass any proxy
-	conn, err := grpc.Dial(url, 
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	opts := append(authn.DialOptions(auth.ServiceOrder),
+		tracing.DialOption(),
 		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy":"round_robin"}`),
 	)
+	conn, err := grpc.Dial(url, opts...)
 	if err != nil {
 		log.Printf("❌ Order client connection failed: %v", err)
 		return nil, err
@@ -36,8 +44,13 @@ func (c *Client) Close() {
 	c.conn.Close()
 }
 
+// Health reports whether the order service is serving
+func (c *Client) Health(ctx context.Context) error {
+	return health.Probe(ctx, c.conn, pb.OrderService_ServiceDesc.ServiceName)
+}
+
 // PostOrder calls the gRPC PostOrder
-func (c *Client) PostOrder(ctx context.Context, accountID string, products []OrderProduct) (*Order, error) {
+func (c *Client) PostOrder(ctx context.Context, accountID string, products []OrderProduct, idempotencyKey string) (*Order, error) {
 	reqProducts := make([]*pb.PostOrderRequest_OrderProduct, len(products))
 	for i, p := range products {
 		reqProducts[i] = &pb.PostOrderRequest_OrderProduct{
@@ -47,32 +60,29 @@ func (c *Client) PostOrder(ctx context.Context, accountID string, products []Ord
 	}
 
 	resp, err := c.service.PostOrder(ctx, &pb.PostOrderRequest{
-		AccountId: accountID,
-		Products:  reqProducts,
+		AccountId:      accountID,
+		Products:       reqProducts,
+		IdempotencyKey: idempotencyKey,
 	})
 	if err != nil {
 		log.Println("Error posting order:", err)
 		return nil, err
 	}
 
-	orderProducts := make([]OrderProduct, len(resp.Order.Products))
-	for i, p := range resp.Order.Products {
-		orderProducts[i] = OrderProduct{
-			ID:          p.Id,
-			Name:        p.Name,
-			Description: p.Description,
-			Quantity:    p.Quantity,
-			Price:       p.Price,
-		}
+	return convertOrderProtoToOrder(resp.Order), nil
+}
+
+// GetOrder calls gRPC GetOrder
+func (c *Client) GetOrder(ctx context.Context, id string) (*Order, error) {
+	resp, err := c.service.GetOrder(ctx, &pb.GetOrderRequest{
+		Id: id,
+	})
+	if err != nil {
+		log.Println("Error fetching order:", err)
+		return nil, err
 	}
 
-	return &Order{
-		ID:         resp.Order.Id,
-		AccountID:  resp.Order.AccountId,
-		TotalPrice: resp.Order.TotalPrice,
-		CreatedAt:  resp.Order.GetCreatedAt().AsTime(),
-		Products:   orderProducts,
-	}, nil
+	return convertOrderProtoToOrder(resp.Order), nil
 }
 
 // GetOrdersForAccount calls gRPC GetOrderForAccount
@@ -87,18 +97,174 @@ func (c *Client) GetOrdersForAccount(ctx context.Context, accountID string) ([]O
 
 	orders := make([]Order, len(resp.Orders))
 	for i, o := range resp.Orders {
-		orders[i] = Order{
-			ID:         o.Id,
-			AccountID:  o.AccountId,
-			TotalPrice: o.TotalPrice,
-			CreatedAt:  o.GetCreatedAt().AsTime(),
-			Products:   convertOrderProtoToOrderProducts(o.Products),
-		}
+		orders[i] = *convertOrderProtoToOrder(o)
 	}
 
 	return orders, nil
 }
 
+// ListOrders calls gRPC ListOrders. An empty cursor starts at the first page
+// and an empty sort lists newest first.
+func (c *Client) ListOrders(ctx context.Context, filter OrderFilter, sortBy OrderSort, after string, first uint64) (*OrderPage, error) {
+	req := convertListingToProto(filter, sortBy, after, first)
+	resp, err := c.service.ListOrders(ctx, req)
+	if err != nil {
+		log.Println("Error listing orders:", err)
+		return nil, err
+	}
+
+	page := &OrderPage{
+		Orders:      make([]Order, len(resp.Orders)),
+		Cursors:     resp.Cursors,
+		HasNextPage: resp.HasNextPage,
+	}
+	for i, o := range resp.Orders {
+		page.Orders[i] = *convertOrderProtoToOrder(o)
+	}
+	return page, nil
+}
+
+// ListOrdersForAccounts calls gRPC ListOrdersForAccounts: the first page of
+// orders of each account, keyed by account ID. filter.AccountID is ignored.
+func (c *Client) ListOrdersForAccounts(ctx context.Context, accountIDs []string, filter OrderFilter, sortBy OrderSort, first uint64) (map[string]*OrderPage, error) {
+	filter.AccountID = ""
+	resp, err := c.service.ListOrdersForAccounts(ctx, &pb.ListOrdersForAccountsRequest{
+		AccountIds: accountIDs,
+		Query:      convertListingToProto(filter, sortBy, "", first),
+	})
+	if err != nil {
+		log.Println("Error listing orders for accounts:", err)
+		return nil, err
+	}
+
+	pages := make(map[string]*OrderPage, len(resp.Accounts))
+	for _, a := range resp.Accounts {
+		page := &OrderPage{
+			Orders:      make([]Order, len(a.Orders)),
+			Cursors:     a.Cursors,
+			HasNextPage: a.HasNextPage,
+		}
+		for i, o := range a.Orders {
+			page.Orders[i] = *convertOrderProtoToOrder(o)
+		}
+		pages[a.AccountId] = page
+	}
+	return pages, nil
+}
+
+// HasOpenOrders reports whether an account has orders that are neither
+// delivered nor cancelled
+func (c *Client) HasOpenOrders(ctx context.Context, accountID string) (bool, error) {
+	filter := OrderFilter{
+		AccountID: accountID,
+		Statuses:  []OrderStatus{StatusPending, StatusPaid, StatusFulfilled, StatusShipped},
+	}
+	page, err := c.ListOrders(ctx, filter, "", "", 1)
+	if err != nil {
+		return false, err
+	}
+	return len(page.Orders) > 0, nil
+}
+
+// WatchOrders calls gRPC WatchOrders and delivers its events on the returned
+// channel: those of one order, of one account's orders, or all if both IDs
+// are empty. The channel is closed when ctx is done or the stream breaks.
+func (c *Client) WatchOrders(ctx context.Context, orderID string, accountID string) (<-chan OrderEvent, error) {
+	stream, err := c.service.WatchOrders(ctx, &pb.WatchOrdersRequest{
+		OrderId:   orderID,
+		AccountId: accountID,
+	})
+	if err != nil {
+		log.Println("Error watching orders:", err)
+		return nil, err
+	}
+
+	events := make(chan OrderEvent)
+	go func() {
+		defer close(events)
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() == nil && err != io.EOF {
+					log.Println("Error receiving order event:", err)
+				}
+				return
+			}
+			select {
+			case events <- OrderEvent{Type: OrderEventType(e.Type), Order: *convertOrderProtoToOrder(e.Order)}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// UpdateOrderStatus calls gRPC UpdateOrderStatus
+func (c *Client) UpdateOrderStatus(ctx context.Context, id string, status OrderStatus) (*Order, error) {
+	resp, err := c.service.UpdateOrderStatus(ctx, &pb.UpdateOrderStatusRequest{
+		Id:     id,
+		Status: string(status),
+	})
+	if err != nil {
+		log.Println("Error updating order status:", err)
+		return nil, err
+	}
+
+	return convertOrderProtoToOrder(resp.Order), nil
+}
+
+// CancelOrder calls gRPC CancelOrder
+func (c *Client) CancelOrder(ctx context.Context, id string) (*Order, error) {
+	resp, err := c.service.CancelOrder(ctx, &pb.CancelOrderRequest{
+		Id: id,
+	})
+	if err != nil {
+		log.Println("Error cancelling order:", err)
+		return nil, err
+	}
+
+	return convertOrderProtoToOrder(resp.Order), nil
+}
+
+// Helper: convert listing arguments to a ListOrders request
+func convertListingToProto(filter OrderFilter, sortBy OrderSort, after string, first uint64) *pb.ListOrdersRequest {
+	req := &pb.ListOrdersRequest{
+		AccountId: filter.AccountID,
+		First:     first,
+		After:     after,
+		Sort:      string(sortBy),
+	}
+	if !filter.CreatedAfter.IsZero() {
+		req.CreatedAfter = timestamppb.New(filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		req.CreatedBefore = timestamppb.New(filter.CreatedBefore)
+	}
+	for _, s := range filter.Statuses {
+		req.Statuses = append(req.Statuses, string(s))
+	}
+	if filter.MinTotal != nil {
+		req.MinTotal = convertMoneyToProto(*filter.MinTotal)
+	}
+	if filter.MaxTotal != nil {
+		req.MaxTotal = convertMoneyToProto(*filter.MaxTotal)
+	}
+	return req
+}
+
+// Helper: convert response order to internal Order
+func convertOrderProtoToOrder(o *pb.Order) *Order {
+	return &Order{
+		ID:         o.Id,
+		AccountID:  o.AccountId,
+		TotalPrice: convertProtoToMoney(o.TotalPrice),
+		CreatedAt:  o.GetCreatedAt().AsTime(),
+		Status:     OrderStatus(o.Status),
+		Products:   convertOrderProtoToOrderProducts(o.Products),
+	}
+}
+
 // Helper: convert response products to internal OrderProduct
 func convertOrderProtoToOrderProducts(protoProducts []*pb.Order_OrderProduct) []OrderProduct {
 	products := make([]OrderProduct, len(protoProducts))
@@ -108,8 +274,13 @@ func convertOrderProtoToOrderProducts(protoProducts []*pb.Order_OrderProduct) []
 			Name:        p.Name,
 			Description: p.Description,
 			Quantity:    p.Quantity,
-			Price:       p.Price,
+			Price:       convertProtoToMoney(p.Price),
 		}
 	}
 	return products
 }
+
+// Helper: convert protobuf Money to Money
+func convertProtoToMoney(m *pb.Money) money.Money {
+	return money.New(m.GetAmount(), m.GetCurrency())
+}
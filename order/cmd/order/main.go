@@ -1,19 +1,27 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/avast/retry-go"
 	"github.com/joho/godotenv"
+	"github.com/pawan-sharma-12/go_microservices/auth"
+	"github.com/pawan-sharma-12/go_microservices/graceful"
+	"github.com/pawan-sharma-12/go_microservices/health"
 	"github.com/pawan-sharma-12/go_microservices/order"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 )
 
 type Config struct {
-	OrderDatabaseURL string `envconfig:"ORDER_DATABASE_URL,required"`
-	AccountURL       string `envconfig:"ACCOUNT_SERVICE_URL,required"`
-	CatalogURL       string `envconfig:"CATALOG_SERVICE_URL,required"`
+	OrderDatabaseURL string        `envconfig:"ORDER_DATABASE_URL,required"`
+	AccountURL       string        `envconfig:"ACCOUNT_SERVICE_URL,required"`
+	CatalogURL       string        `envconfig:"CATALOG_SERVICE_URL,required"`
+	ProductCacheTTL  time.Duration `envconfig:"PRODUCT_CACHE_TTL"`
+	ProductCacheSize int           `envconfig:"PRODUCT_CACHE_SIZE"`
 }
 
 func main() {
@@ -33,6 +41,11 @@ func main() {
 		log.Printf("📦 Loaded environment config from %s", envFile)
 	}
 
+	flushSpans, err := tracing.Setup(context.Background(), auth.ServiceOrder)
+	if err != nil {
+		log.Fatalf("Could not set up tracing: %v", err)
+	}
+
 	// -------------------------------
 	// Read configuration
 	// -------------------------------
@@ -40,6 +53,22 @@ func main() {
 		OrderDatabaseURL: os.Getenv("ORDER_DATABASE_URL"),
 		AccountURL:       os.Getenv("ACCOUNT_SERVICE_URL"),
 		CatalogURL:       os.Getenv("CATALOG_SERVICE_URL"),
+		ProductCacheTTL:  order.DefaultProductCacheConfig.TTL,
+		ProductCacheSize: order.DefaultProductCacheConfig.MaxEntries,
+	}
+	if v := os.Getenv("PRODUCT_CACHE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("❌ Invalid PRODUCT_CACHE_TTL %q: %v", v, err)
+		}
+		cfg.ProductCacheTTL = ttl
+	}
+	if v := os.Getenv("PRODUCT_CACHE_SIZE"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("❌ Invalid PRODUCT_CACHE_SIZE %q: %v", v, err)
+		}
+		cfg.ProductCacheSize = size
 	}
 
 	// -------------------------------
@@ -54,16 +83,24 @@ func main() {
 	if cfg.CatalogURL == "" {
 		log.Fatal("❌ CATALOG_SERVICE_URL not set")
 	}
+	authn, err := auth.NewAuthenticatorFromEnv(auth.ServiceOrder)
+	if err != nil {
+		log.Fatalf("❌ Invalid service auth config: %v", err)
+	}
+	if err := graceful.TimeoutFromEnv(); err != nil {
+		log.Fatalf("❌ Invalid shutdown timeout: %v", err)
+	}
 
 	log.Println("🔗 ORDER_DATABASE_URL:", cfg.OrderDatabaseURL)
 	log.Println("🔗 ACCOUNT_SERVICE_URL:", cfg.AccountURL)
 	log.Println("🔗 CATALOG_SERVICE_URL:", cfg.CatalogURL)
+	log.Printf("📦 Product cache: ttl=%s size=%d", cfg.ProductCacheTTL, cfg.ProductCacheSize)
 
 	// -------------------------------
 	// Retry DB connection
 	// -------------------------------
 	var r order.Repository
-	err := retry.Do(
+	err = retry.Do(
 		func() error {
 			var err error
 			r, err = order.NewPostgresRepository(cfg.OrderDatabaseURL)
@@ -79,12 +116,24 @@ func main() {
 	if err != nil {
 		log.Fatalf("💥 Could not connect to Order DB after retries: %v", err)
 	}
-	defer r.Close()
 
 	// -------------------------------
 	// Start gRPC server
 	// -------------------------------
 	log.Println("🚀 Order Service listening on port 50053...")
 	s := order.NewService(r)
-	log.Fatal(order.ListenGRPC(s, cfg.AccountURL, cfg.CatalogURL, 50053))
+	cache := order.ProductCacheConfig{
+		TTL:        cfg.ProductCacheTTL,
+		MaxEntries: cfg.ProductCacheSize,
+	}
+	ctx, stop := graceful.SignalContext()
+	defer stop()
+	// ListenGRPC closes its clients once drained; the database goes last
+	err = order.ListenGRPC(ctx, s, cfg.AccountURL, cfg.CatalogURL, 50053, cache, authn, health.Checks{"postgres": r.Ping})
+	r.Close()
+	flushSpans()
+	if err != nil {
+		log.Fatalf("💥 Order service failed: %v", err)
+	}
+	log.Println("👋 Order service stopped")
 }
@@ -0,0 +1,238 @@
+package order
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/cursor"
+	"github.com/pawan-sharma-12/go_microservices/money"
+)
+
+// OrderFilter selects the orders of a listing. Zero fields match all orders.
+type OrderFilter struct {
+	AccountID string
+	// CreatedAfter is inclusive, CreatedBefore exclusive
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Statuses      []OrderStatus
+	// MinTotal and MaxTotal are inclusive and only match orders in their
+	// currency
+	MinTotal *money.Money
+	MaxTotal *money.Money
+}
+
+// OrderSort is the order of a listing. Orders that tie are ordered by ID in
+// the same direction, so every sort is stable across pages.
+type OrderSort string
+
+const (
+	SortCreatedAtDesc OrderSort = "created_at_desc"
+	SortCreatedAtAsc  OrderSort = "created_at_asc"
+	SortTotalDesc     OrderSort = "total_desc"
+	SortTotalAsc      OrderSort = "total_asc"
+)
+
+// OrderPage is one page of a cursor-paginated order listing
+type OrderPage struct {
+	Orders []Order
+	// Cursors[i] is the position just after Orders[i]
+	Cursors     []string
+	HasNextPage bool
+}
+
+// orderCursor is the position encoded in order page cursors: the sort keys
+// of the last order of the page
+type orderCursor struct {
+	Sort      OrderSort `json:"s"`
+	CreatedAt time.Time `json:"c"`
+	Total     int64     `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// maxPageSize caps how many orders one page returns
+const maxPageSize = 100
+
+var ErrInvalidFilter = errors.New("invalid order filter")
+
+// ParseOrderSort reads a sort name. The empty string is the default,
+// newest first.
+func ParseOrderSort(s string) (OrderSort, error) {
+	switch sort := OrderSort(s); sort {
+	case "":
+		return SortCreatedAtDesc, nil
+	case SortCreatedAtDesc, SortCreatedAtAsc, SortTotalDesc, SortTotalAsc:
+		return sort, nil
+	default:
+		return "", fmt.Errorf("%w: unknown sort %q", ErrInvalidFilter, s)
+	}
+}
+
+func (s OrderSort) descending() bool {
+	return s == SortCreatedAtDesc || s == SortTotalDesc
+}
+
+func (s OrderSort) byTotal() bool {
+	return s == SortTotalAsc || s == SortTotalDesc
+}
+
+// Validate checks that the total bounds are valid amounts of one currency
+func (f OrderFilter) Validate() error {
+	for _, bound := range []*money.Money{f.MinTotal, f.MaxTotal} {
+		if bound == nil {
+			continue
+		}
+		if err := bound.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+		}
+	}
+	if f.MinTotal != nil && f.MaxTotal != nil && f.MinTotal.Currency != f.MaxTotal.Currency {
+		return fmt.Errorf("%w: minimum and maximum total have different currencies", ErrInvalidFilter)
+	}
+	for _, status := range f.Statuses {
+		if _, err := ParseOrderStatus(string(status)); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether an order passes the filter
+func (f OrderFilter) matches(o Order) bool {
+	if f.AccountID != "" && o.AccountID != f.AccountID {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && o.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !o.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, status := range f.Statuses {
+			found = found || o.Status == status
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.MinTotal != nil && (o.TotalPrice.Currency != f.MinTotal.Currency || o.TotalPrice.Amount < f.MinTotal.Amount) {
+		return false
+	}
+	if f.MaxTotal != nil && (o.TotalPrice.Currency != f.MaxTotal.Currency || o.TotalPrice.Amount > f.MaxTotal.Amount) {
+		return false
+	}
+	return true
+}
+
+// compare returns a negative number if a comes before b in the sort order,
+// a positive one if it comes after, and zero if they are the same order
+func (s OrderSort) compare(a, b Order) int {
+	var c int
+	if s.byTotal() {
+		c = cmp.Compare(a.TotalPrice.Amount, b.TotalPrice.Amount)
+	} else {
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	if s.descending() {
+		return -c
+	}
+	return c
+}
+
+// ListOrders pages through the orders matching filter in the given sort order
+func (s *OrderService) ListOrders(ctx context.Context, filter OrderFilter, sortBy OrderSort, after string, first uint64) (*OrderPage, error) {
+	if first == 0 || first > maxPageSize {
+		first = maxPageSize
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if sortBy == "" {
+		sortBy = SortCreatedAtDesc
+	}
+	var position *Order
+	if after != "" {
+		var c orderCursor
+		if err := cursor.Decode(after, &c); err != nil {
+			return nil, err
+		}
+		if c.Sort != sortBy {
+			return nil, fmt.Errorf("%w: cursor belongs to a different sort", cursor.ErrInvalid)
+		}
+		position = &Order{
+			ID:         c.ID,
+			CreatedAt:  c.CreatedAt,
+			TotalPrice: money.Money{Amount: c.Total},
+		}
+	}
+
+	// One extra order tells whether there is a next page
+	orders, err := s.repo.ListOrders(ctx, filter, sortBy, position, first+1)
+	if err != nil {
+		return nil, err
+	}
+	return newOrderPage(orders, sortBy, first), nil
+}
+
+// ListOrdersForAccounts returns the first page of ListOrders for each of
+// several accounts at once, keyed by account ID. filter.AccountID is ignored.
+// Every requested account has a page, which is empty if it has no orders.
+func (s *OrderService) ListOrdersForAccounts(ctx context.Context, accountIDs []string, filter OrderFilter, sortBy OrderSort, first uint64) (map[string]*OrderPage, error) {
+	if first == 0 || first > maxPageSize {
+		first = maxPageSize
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if sortBy == "" {
+		sortBy = SortCreatedAtDesc
+	}
+	pages := make(map[string]*OrderPage, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return pages, nil
+	}
+
+	orders, err := s.repo.ListOrdersForAccounts(ctx, accountIDs, filter, sortBy, first+1)
+	if err != nil {
+		return nil, err
+	}
+	byAccount := make(map[string][]Order, len(accountIDs))
+	for _, o := range orders {
+		byAccount[o.AccountID] = append(byAccount[o.AccountID], o)
+	}
+	for _, id := range accountIDs {
+		pages[id] = newOrderPage(byAccount[id], sortBy, first)
+	}
+	return pages, nil
+}
+
+// Helper: build a page from up to first+1 orders in sortBy order, the extra
+// one only telling that there is a next page
+func newOrderPage(orders []Order, sortBy OrderSort, first uint64) *OrderPage {
+	page := &OrderPage{HasNextPage: uint64(len(orders)) > first}
+	if page.HasNextPage {
+		orders = orders[:first]
+	}
+	if orders == nil {
+		orders = []Order{}
+	}
+	page.Orders = orders
+	page.Cursors = make([]string, len(orders))
+	for i, o := range orders {
+		page.Cursors[i] = cursor.Encode(orderCursor{
+			Sort:      sortBy,
+			CreatedAt: o.CreatedAt,
+			Total:     o.TotalPrice.Amount,
+			ID:        o.ID,
+		})
+	}
+	return page
+}
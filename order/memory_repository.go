@@ -0,0 +1,153 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryRepository keeps orders in memory. It is safe for concurrent use
+// and behaves like the Postgres repository, so it can stand in for it in
+// tests and local runs.
+type memoryRepository struct {
+	mu     sync.RWMutex
+	orders map[string]Order
+	// idempotencyKeys maps account ID and key to an order ID
+	idempotencyKeys map[[2]string]string
+}
+
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		orders:          make(map[string]Order),
+		idempotencyKeys: make(map[[2]string]string),
+	}
+}
+
+func (r *memoryRepository) Close() {}
+
+func (r *memoryRepository) Ping(ctx context.Context) error { return nil }
+
+func (r *memoryRepository) PutOrder(ctx context.Context, order Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.orders[order.ID]; ok {
+		return fmt.Errorf("order %s already exists", order.ID)
+	}
+	key := [2]string{order.AccountID, order.IdempotencyKey}
+	if order.IdempotencyKey != "" {
+		if _, ok := r.idempotencyKeys[key]; ok {
+			return ErrDuplicateIdempotencyKey
+		}
+		r.idempotencyKeys[key] = order.ID
+	}
+	r.orders[order.ID] = copyOrder(order)
+	return nil
+}
+
+func (r *memoryRepository) GetOrder(ctx context.Context, id string) (*Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	order = copyOrder(order)
+	return &order, nil
+}
+
+func (r *memoryRepository) GetOrderByIdempotencyKey(ctx context.Context, accountID string, key string) (*Order, error) {
+	r.mu.RLock()
+	id, ok := r.idempotencyKeys[[2]string{accountID, key}]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r.GetOrder(ctx, id)
+}
+
+// GetOrdersForAccount returns the account's orders, oldest first
+func (r *memoryRepository) GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error) {
+	r.mu.RLock()
+	orders := []Order{}
+	for _, o := range r.orders {
+		if o.AccountID == accountID {
+			o = copyOrder(o)
+			o.IdempotencyKey = ""
+			o.RequestHash = ""
+			orders = append(orders, o)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+		}
+		return orders[i].ID < orders[j].ID
+	})
+	return orders, nil
+}
+
+func (r *memoryRepository) ListOrders(ctx context.Context, filter OrderFilter, sortBy OrderSort, after *Order, first uint64) ([]Order, error) {
+	r.mu.RLock()
+	orders := []Order{}
+	for _, o := range r.orders {
+		if !filter.matches(o) {
+			continue
+		}
+		if after != nil && sortBy.compare(o, *after) <= 0 {
+			continue
+		}
+		o = copyOrder(o)
+		o.IdempotencyKey = ""
+		o.RequestHash = ""
+		orders = append(orders, o)
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(orders, sortBy.compare)
+	if first < uint64(len(orders)) {
+		orders = orders[:first]
+	}
+	return orders, nil
+}
+
+func (r *memoryRepository) ListOrdersForAccounts(ctx context.Context, accountIDs []string, filter OrderFilter, sortBy OrderSort, first uint64) ([]Order, error) {
+	ids := slices.Clone(accountIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	orders := []Order{}
+	for _, id := range ids {
+		filter.AccountID = id
+		accountOrders, err := r.ListOrders(ctx, filter, sortBy, nil, first)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, accountOrders...)
+	}
+	return orders, nil
+}
+
+// UpdateOrderStatus fails with ErrInvalidTransition unless the order is
+// currently in the from status
+func (r *memoryRepository) UpdateOrderStatus(ctx context.Context, id string, from OrderStatus, to OrderStatus, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[id]
+	if !ok || order.Status != from {
+		return ErrInvalidTransition
+	}
+	order.Status = to
+	r.orders[id] = order
+	return nil
+}
+
+// Helper: copy an order so callers cannot change the stored products
+func copyOrder(o Order) Order {
+	o.Products = append([]OrderProduct{}, o.Products...)
+	return o
+}
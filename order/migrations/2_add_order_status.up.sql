@@ -0,0 +1,15 @@
+-- Order status
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'paid', 'fulfilled', 'shipped', 'delivered', 'cancelled'));
+
+-- Order status transitions
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_id CHAR(27) NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status VARCHAR(16),
+    to_status VARCHAR(16) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_idx ON order_status_history (order_id, changed_at);
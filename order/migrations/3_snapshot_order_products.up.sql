@@ -0,0 +1,6 @@
+-- Purchase-time product snapshot. Rows written before this migration keep
+-- empty values and are filled from the catalog when read.
+ALTER TABLE order_products
+    ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS price DOUBLE PRECISION NOT NULL DEFAULT 0;
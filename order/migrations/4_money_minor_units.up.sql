@@ -0,0 +1,44 @@
+-- Store prices as integer minor units with an ISO 4217 currency code.
+-- Existing float amounts are treated as USD and scaled to its minor unit.
+-- The backfills only run while the float columns are still there, so the
+-- migration can be applied again.
+BEGIN;
+
+-- Same exponents as money.Exponent
+CREATE OR REPLACE FUNCTION pg_temp.minor_unit_scale(currency TEXT) RETURNS NUMERIC AS $$
+    SELECT POWER(10, CASE currency
+        WHEN 'CLP' THEN 0 WHEN 'ISK' THEN 0 WHEN 'JPY' THEN 0 WHEN 'KRW' THEN 0 WHEN 'VND' THEN 0
+        WHEN 'BHD' THEN 3 WHEN 'IQD' THEN 3 WHEN 'JOD' THEN 3 WHEN 'KWD' THEN 3 WHEN 'OMR' THEN 3 WHEN 'TND' THEN 3
+        ELSE 2
+    END)::NUMERIC
+$$ LANGUAGE SQL IMMUTABLE;
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS total_amount BIGINT,
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'orders' AND column_name = 'total_price') THEN
+        UPDATE orders SET total_amount = ROUND(total_price * pg_temp.minor_unit_scale(currency)) WHERE total_amount IS NULL;
+    END IF;
+END $$;
+ALTER TABLE orders
+    ALTER COLUMN total_amount SET NOT NULL,
+    DROP COLUMN IF EXISTS total_price;
+
+ALTER TABLE order_products
+    ADD COLUMN IF NOT EXISTS price_amount BIGINT,
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'order_products' AND column_name = 'price') THEN
+        UPDATE order_products SET price_amount = ROUND(price * pg_temp.minor_unit_scale(currency)) WHERE price_amount IS NULL;
+    END IF;
+END $$;
+ALTER TABLE order_products
+    ALTER COLUMN price_amount SET NOT NULL,
+    DROP COLUMN IF EXISTS price;
+
+COMMIT;
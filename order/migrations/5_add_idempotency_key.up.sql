@@ -0,0 +1,6 @@
+-- Idempotency keys for order creation, unique per account
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS request_hash CHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS orders_account_idempotency_key_key ON orders (account_id, idempotency_key);
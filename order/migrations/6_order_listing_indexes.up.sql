@@ -0,0 +1,4 @@
+-- Keyset pagination of order listings, per account and overall
+CREATE INDEX IF NOT EXISTS orders_account_created_at_idx ON orders (account_id, created_at, id);
+CREATE INDEX IF NOT EXISTS orders_account_total_idx ON orders (account_id, total_amount, id);
+CREATE INDEX IF NOT EXISTS orders_created_at_idx ON orders (created_at, id);
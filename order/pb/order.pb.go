@@ -22,20 +22,74 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Money is an amount in the minor unit of an ISO 4217 currency
+type Money struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Amount        int64                  `protobuf:"varint,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency      string                 `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Money) Reset() {
+	*x = Money{}
+	mi := &file_order_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Money) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Money) ProtoMessage() {}
+
+func (x *Money) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Money.ProtoReflect.Descriptor instead.
+func (*Money) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Money) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Money) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
 type Order struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	AccountId     string                 `protobuf:"bytes,3,opt,name=accountId,proto3" json:"accountId,omitempty"`
-	TotalPrice    float64                `protobuf:"fixed64,4,opt,name=totalPrice,proto3" json:"totalPrice,omitempty"`
 	Products      []*Order_OrderProduct  `protobuf:"bytes,5,rep,name=Products,proto3" json:"Products,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	TotalPrice    *Money                 `protobuf:"bytes,7,opt,name=totalPrice,proto3" json:"totalPrice,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Order) Reset() {
 	*x = Order{}
-	mi := &file_order_proto_msgTypes[0]
+	mi := &file_order_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -47,7 +101,7 @@ func (x *Order) String() string {
 func (*Order) ProtoMessage() {}
 
 func (x *Order) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[0]
+	mi := &file_order_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -60,7 +114,7 @@ func (x *Order) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Order.ProtoReflect.Descriptor instead.
 func (*Order) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{0}
+	return file_order_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *Order) GetId() string {
@@ -84,31 +138,40 @@ func (x *Order) GetAccountId() string {
 	return ""
 }
 
-func (x *Order) GetTotalPrice() float64 {
+func (x *Order) GetProducts() []*Order_OrderProduct {
 	if x != nil {
-		return x.TotalPrice
+		return x.Products
 	}
-	return 0
+	return nil
 }
 
-func (x *Order) GetProducts() []*Order_OrderProduct {
+func (x *Order) GetStatus() string {
 	if x != nil {
-		return x.Products
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Order) GetTotalPrice() *Money {
+	if x != nil {
+		return x.TotalPrice
 	}
 	return nil
 }
 
 type PostOrderRequest struct {
-	state         protoimpl.MessageState           `protogen:"open.v1"`
-	AccountId     string                           `protobuf:"bytes,2,opt,name=AccountId,proto3" json:"AccountId,omitempty"`
-	Products      []*PostOrderRequest_OrderProduct `protobuf:"bytes,3,rep,name=Products,proto3" json:"Products,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state     protoimpl.MessageState           `protogen:"open.v1"`
+	AccountId string                           `protobuf:"bytes,2,opt,name=AccountId,proto3" json:"AccountId,omitempty"`
+	Products  []*PostOrderRequest_OrderProduct `protobuf:"bytes,3,rep,name=Products,proto3" json:"Products,omitempty"`
+	// Retries with the same key and payload return the original order
+	IdempotencyKey string `protobuf:"bytes,4,opt,name=idempotencyKey,proto3" json:"idempotencyKey,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *PostOrderRequest) Reset() {
 	*x = PostOrderRequest{}
-	mi := &file_order_proto_msgTypes[1]
+	mi := &file_order_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -120,7 +183,7 @@ func (x *PostOrderRequest) String() string {
 func (*PostOrderRequest) ProtoMessage() {}
 
 func (x *PostOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[1]
+	mi := &file_order_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -133,7 +196,7 @@ func (x *PostOrderRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PostOrderRequest.ProtoReflect.Descriptor instead.
 func (*PostOrderRequest) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{1}
+	return file_order_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *PostOrderRequest) GetAccountId() string {
@@ -150,6 +213,13 @@ func (x *PostOrderRequest) GetProducts() []*PostOrderRequest_OrderProduct {
 	return nil
 }
 
+func (x *PostOrderRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
 type PostOrderResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Order         *Order                 `protobuf:"bytes,1,opt,name=Order,proto3" json:"Order,omitempty"`
@@ -159,7 +229,7 @@ type PostOrderResponse struct {
 
 func (x *PostOrderResponse) Reset() {
 	*x = PostOrderResponse{}
-	mi := &file_order_proto_msgTypes[2]
+	mi := &file_order_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -171,7 +241,7 @@ func (x *PostOrderResponse) String() string {
 func (*PostOrderResponse) ProtoMessage() {}
 
 func (x *PostOrderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[2]
+	mi := &file_order_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -184,7 +254,7 @@ func (x *PostOrderResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PostOrderResponse.ProtoReflect.Descriptor instead.
 func (*PostOrderResponse) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{2}
+	return file_order_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *PostOrderResponse) GetOrder() *Order {
@@ -203,7 +273,7 @@ type GetOrderRequest struct {
 
 func (x *GetOrderRequest) Reset() {
 	*x = GetOrderRequest{}
-	mi := &file_order_proto_msgTypes[3]
+	mi := &file_order_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -215,7 +285,7 @@ func (x *GetOrderRequest) String() string {
 func (*GetOrderRequest) ProtoMessage() {}
 
 func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[3]
+	mi := &file_order_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -228,7 +298,7 @@ func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
 func (*GetOrderRequest) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{3}
+	return file_order_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetOrderRequest) GetId() string {
@@ -247,7 +317,7 @@ type GetOrderResponse struct {
 
 func (x *GetOrderResponse) Reset() {
 	*x = GetOrderResponse{}
-	mi := &file_order_proto_msgTypes[4]
+	mi := &file_order_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -259,7 +329,7 @@ func (x *GetOrderResponse) String() string {
 func (*GetOrderResponse) ProtoMessage() {}
 
 func (x *GetOrderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[4]
+	mi := &file_order_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -272,7 +342,7 @@ func (x *GetOrderResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOrderResponse.ProtoReflect.Descriptor instead.
 func (*GetOrderResponse) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{4}
+	return file_order_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetOrderResponse) GetOrder() *Order {
@@ -291,7 +361,7 @@ type GetOrderForAccountRequest struct {
 
 func (x *GetOrderForAccountRequest) Reset() {
 	*x = GetOrderForAccountRequest{}
-	mi := &file_order_proto_msgTypes[5]
+	mi := &file_order_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -303,7 +373,7 @@ func (x *GetOrderForAccountRequest) String() string {
 func (*GetOrderForAccountRequest) ProtoMessage() {}
 
 func (x *GetOrderForAccountRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[5]
+	mi := &file_order_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -316,7 +386,7 @@ func (x *GetOrderForAccountRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOrderForAccountRequest.ProtoReflect.Descriptor instead.
 func (*GetOrderForAccountRequest) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{5}
+	return file_order_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetOrderForAccountRequest) GetAccountId() string {
@@ -335,7 +405,7 @@ type GetOrderForAccountResponse struct {
 
 func (x *GetOrderForAccountResponse) Reset() {
 	*x = GetOrderForAccountResponse{}
-	mi := &file_order_proto_msgTypes[6]
+	mi := &file_order_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -347,7 +417,7 @@ func (x *GetOrderForAccountResponse) String() string {
 func (*GetOrderForAccountResponse) ProtoMessage() {}
 
 func (x *GetOrderForAccountResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[6]
+	mi := &file_order_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -360,7 +430,7 @@ func (x *GetOrderForAccountResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetOrderForAccountResponse.ProtoReflect.Descriptor instead.
 func (*GetOrderForAccountResponse) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{6}
+	return file_order_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetOrderForAccountResponse) GetOrders() []*Order {
@@ -370,32 +440,44 @@ func (x *GetOrderForAccountResponse) GetOrders() []*Order {
 	return nil
 }
 
-type Order_OrderProduct struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
-	Price         float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
-	Quantity      uint64                 `protobuf:"varint,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+type ListOrdersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only orders of this account if set
+	AccountId string `protobuf:"bytes,1,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	// At most first orders (default and maximum 100) are returned, starting
+	// after the given cursor
+	First uint64 `protobuf:"varint,2,opt,name=first,proto3" json:"first,omitempty"`
+	After string `protobuf:"bytes,3,opt,name=after,proto3" json:"after,omitempty"`
+	// Filters, unset ones match all orders. createdAfter is inclusive,
+	// createdBefore exclusive. The total bounds are inclusive and only match
+	// orders in their currency.
+	CreatedAfter  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=createdAfter,proto3" json:"createdAfter,omitempty"`
+	CreatedBefore *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=createdBefore,proto3" json:"createdBefore,omitempty"`
+	Statuses      []string               `protobuf:"bytes,6,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	MinTotal      *Money                 `protobuf:"bytes,7,opt,name=minTotal,proto3" json:"minTotal,omitempty"`
+	MaxTotal      *Money                 `protobuf:"bytes,8,opt,name=maxTotal,proto3" json:"maxTotal,omitempty"`
+	// created_at_desc (default), created_at_asc, total_desc or total_asc.
+	// Ties are broken by order ID.
+	Sort          string `protobuf:"bytes,9,opt,name=sort,proto3" json:"sort,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Order_OrderProduct) Reset() {
-	*x = Order_OrderProduct{}
-	mi := &file_order_proto_msgTypes[7]
+func (x *ListOrdersRequest) Reset() {
+	*x = ListOrdersRequest{}
+	mi := &file_order_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Order_OrderProduct) String() string {
+func (x *ListOrdersRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Order_OrderProduct) ProtoMessage() {}
+func (*ListOrdersRequest) ProtoMessage() {}
 
-func (x *Order_OrderProduct) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[7]
+func (x *ListOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -406,69 +488,160 @@ func (x *Order_OrderProduct) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Order_OrderProduct.ProtoReflect.Descriptor instead.
-func (*Order_OrderProduct) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{0, 0}
+// Deprecated: Use ListOrdersRequest.ProtoReflect.Descriptor instead.
+func (*ListOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *Order_OrderProduct) GetId() string {
+func (x *ListOrdersRequest) GetAccountId() string {
 	if x != nil {
-		return x.Id
+		return x.AccountId
 	}
 	return ""
 }
 
-func (x *Order_OrderProduct) GetName() string {
+func (x *ListOrdersRequest) GetFirst() uint64 {
 	if x != nil {
-		return x.Name
+		return x.First
+	}
+	return 0
+}
+
+func (x *ListOrdersRequest) GetAfter() string {
+	if x != nil {
+		return x.After
 	}
 	return ""
 }
 
-func (x *Order_OrderProduct) GetDescription() string {
+func (x *ListOrdersRequest) GetCreatedAfter() *timestamppb.Timestamp {
 	if x != nil {
-		return x.Description
+		return x.CreatedAfter
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetCreatedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedBefore
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetStatuses() []string {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetMinTotal() *Money {
+	if x != nil {
+		return x.MinTotal
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetMaxTotal() *Money {
+	if x != nil {
+		return x.MaxTotal
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
 	}
 	return ""
 }
 
-func (x *Order_OrderProduct) GetPrice() float64 {
+type ListOrdersResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Orders []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	// cursors[i] is the position just after orders[i]
+	Cursors       []string `protobuf:"bytes,2,rep,name=cursors,proto3" json:"cursors,omitempty"`
+	HasNextPage   bool     `protobuf:"varint,3,opt,name=hasNextPage,proto3" json:"hasNextPage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersResponse) Reset() {
+	*x = ListOrdersResponse{}
+	mi := &file_order_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersResponse) ProtoMessage() {}
+
+func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[9]
 	if x != nil {
-		return x.Price
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *Order_OrderProduct) GetQuantity() uint64 {
+// Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListOrdersResponse) GetOrders() []*Order {
 	if x != nil {
-		return x.Quantity
+		return x.Orders
 	}
-	return 0
+	return nil
 }
 
-type PostOrderRequest_OrderProduct struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProductId     string                 `protobuf:"bytes,2,opt,name=productId,proto3" json:"productId,omitempty"`
-	Quantity      uint64                 `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+func (x *ListOrdersResponse) GetCursors() []string {
+	if x != nil {
+		return x.Cursors
+	}
+	return nil
+}
+
+func (x *ListOrdersResponse) GetHasNextPage() bool {
+	if x != nil {
+		return x.HasNextPage
+	}
+	return false
+}
+
+type ListOrdersForAccountsRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	AccountIds []string               `protobuf:"bytes,1,rep,name=accountIds,proto3" json:"accountIds,omitempty"`
+	// Filters, sort and page size applied to the orders of every account.
+	// Its accountId and after must be unset.
+	Query         *ListOrdersRequest `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PostOrderRequest_OrderProduct) Reset() {
-	*x = PostOrderRequest_OrderProduct{}
-	mi := &file_order_proto_msgTypes[8]
+func (x *ListOrdersForAccountsRequest) Reset() {
+	*x = ListOrdersForAccountsRequest{}
+	mi := &file_order_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PostOrderRequest_OrderProduct) String() string {
+func (x *ListOrdersForAccountsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PostOrderRequest_OrderProduct) ProtoMessage() {}
+func (*ListOrdersForAccountsRequest) ProtoMessage() {}
 
-func (x *PostOrderRequest_OrderProduct) ProtoReflect() protoreflect.Message {
-	mi := &file_order_proto_msgTypes[8]
+func (x *ListOrdersForAccountsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -479,65 +652,649 @@ func (x *PostOrderRequest_OrderProduct) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PostOrderRequest_OrderProduct.ProtoReflect.Descriptor instead.
-func (*PostOrderRequest_OrderProduct) Descriptor() ([]byte, []int) {
-	return file_order_proto_rawDescGZIP(), []int{1, 0}
+// Deprecated: Use ListOrdersForAccountsRequest.ProtoReflect.Descriptor instead.
+func (*ListOrdersForAccountsRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *PostOrderRequest_OrderProduct) GetProductId() string {
+func (x *ListOrdersForAccountsRequest) GetAccountIds() []string {
 	if x != nil {
-		return x.ProductId
+		return x.AccountIds
 	}
-	return ""
+	return nil
 }
 
-func (x *PostOrderRequest_OrderProduct) GetQuantity() uint64 {
+func (x *ListOrdersForAccountsRequest) GetQuery() *ListOrdersRequest {
 	if x != nil {
-		return x.Quantity
+		return x.Query
 	}
-	return 0
+	return nil
 }
 
-var File_order_proto protoreflect.FileDescriptor
+type ListOrdersForAccountsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The first page of orders of each requested account
+	Accounts      []*ListOrdersForAccountsResponse_AccountOrders `protobuf:"bytes,1,rep,name=accounts,proto3" json:"accounts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_order_proto_rawDesc = "" +
-	"\n" +
-	"\vorder.proto\x12\x05order\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd0\x02\n" +
-	"\x05Order\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x129\n" +
-	"\n" +
-	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x1c\n" +
-	"\taccountId\x18\x03 \x01(\tR\taccountId\x12\x1e\n" +
-	"\n" +
-	"totalPrice\x18\x04 \x01(\x01R\n" +
-	"totalPrice\x125\n" +
-	"\bProducts\x18\x05 \x03(\v2\x19.order.Order.OrderProductR\bProducts\x1a\x86\x01\n" +
-	"\fOrderProduct\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
-	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x14\n" +
-	"\x05price\x18\x04 \x01(\x01R\x05price\x12\x1a\n" +
-	"\bquantity\x18\x05 \x01(\x04R\bquantity\"\xbc\x01\n" +
-	"\x10PostOrderRequest\x12\x1c\n" +
-	"\tAccountId\x18\x02 \x01(\tR\tAccountId\x12@\n" +
-	"\bProducts\x18\x03 \x03(\v2$.order.PostOrderRequest.OrderProductR\bProducts\x1aH\n" +
-	"\fOrderProduct\x12\x1c\n" +
-	"\tproductId\x18\x02 \x01(\tR\tproductId\x12\x1a\n" +
-	"\bquantity\x18\x03 \x01(\x04R\bquantity\"7\n" +
-	"\x11PostOrderResponse\x12\"\n" +
-	"\x05Order\x18\x01 \x01(\v2\f.order.OrderR\x05Order\"!\n" +
-	"\x0fGetOrderRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"6\n" +
-	"\x10GetOrderResponse\x12\"\n" +
-	"\x05order\x18\x01 \x01(\v2\f.order.OrderR\x05order\"9\n" +
-	"\x19GetOrderForAccountRequest\x12\x1c\n" +
-	"\taccountId\x18\x01 \x01(\tR\taccountId\"B\n" +
-	"\x1aGetOrderForAccountResponse\x12$\n" +
-	"\x06orders\x18\x01 \x03(\v2\f.order.OrderR\x06orders2\xe6\x01\n" +
-	"\fOrderService\x12>\n" +
-	"\tPostOrder\x12\x17.order.PostOrderRequest\x1a\x18.order.PostOrderResponse\x12;\n" +
-	"\bGetOrder\x12\x16.order.GetOrderRequest\x1a\x17.order.GetOrderResponse\x12Y\n" +
-	"\x12GetOrderForAccount\x12 .order.GetOrderForAccountRequest\x1a!.order.GetOrderForAccountResponseB\x03Z\x01.b\x06proto3"
+func (x *ListOrdersForAccountsResponse) Reset() {
+	*x = ListOrdersForAccountsResponse{}
+	mi := &file_order_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersForAccountsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersForAccountsResponse) ProtoMessage() {}
+
+func (x *ListOrdersForAccountsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersForAccountsResponse.ProtoReflect.Descriptor instead.
+func (*ListOrdersForAccountsResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListOrdersForAccountsResponse) GetAccounts() []*ListOrdersForAccountsResponse_AccountOrders {
+	if x != nil {
+		return x.Accounts
+	}
+	return nil
+}
+
+type WatchOrdersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only events of this order, or of this account's orders, if set
+	OrderId       string `protobuf:"bytes,1,opt,name=orderId,proto3" json:"orderId,omitempty"`
+	AccountId     string `protobuf:"bytes,2,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchOrdersRequest) Reset() {
+	*x = WatchOrdersRequest{}
+	mi := &file_order_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchOrdersRequest) ProtoMessage() {}
+
+func (x *WatchOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchOrdersRequest.ProtoReflect.Descriptor instead.
+func (*WatchOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchOrdersRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *WatchOrdersRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+type OrderEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// created or updated
+	Type          string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Order         *Order `protobuf:"bytes,2,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderEvent) Reset() {
+	*x = OrderEvent{}
+	mi := &file_order_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderEvent) ProtoMessage() {}
+
+func (x *OrderEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderEvent.ProtoReflect.Descriptor instead.
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *OrderEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type UpdateOrderStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateOrderStatusRequest) Reset() {
+	*x = UpdateOrderStatusRequest{}
+	mi := &file_order_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateOrderStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOrderStatusRequest) ProtoMessage() {}
+
+func (x *UpdateOrderStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOrderStatusRequest.ProtoReflect.Descriptor instead.
+func (*UpdateOrderStatusRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UpdateOrderStatusRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateOrderStatusRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type UpdateOrderStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateOrderStatusResponse) Reset() {
+	*x = UpdateOrderStatusResponse{}
+	mi := &file_order_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateOrderStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateOrderStatusResponse) ProtoMessage() {}
+
+func (x *UpdateOrderStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateOrderStatusResponse.ProtoReflect.Descriptor instead.
+func (*UpdateOrderStatusResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UpdateOrderStatusResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type CancelOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderRequest) Reset() {
+	*x = CancelOrderRequest{}
+	mi := &file_order_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderRequest) ProtoMessage() {}
+
+func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderRequest.ProtoReflect.Descriptor instead.
+func (*CancelOrderRequest) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CancelOrderRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CancelOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderResponse) Reset() {
+	*x = CancelOrderResponse{}
+	mi := &file_order_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderResponse) ProtoMessage() {}
+
+func (x *CancelOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderResponse.ProtoReflect.Descriptor instead.
+func (*CancelOrderResponse) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CancelOrderResponse) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type Order_OrderProduct struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Quantity      uint64                 `protobuf:"varint,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Price         *Money                 `protobuf:"bytes,6,opt,name=price,proto3" json:"price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order_OrderProduct) Reset() {
+	*x = Order_OrderProduct{}
+	mi := &file_order_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order_OrderProduct) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order_OrderProduct) ProtoMessage() {}
+
+func (x *Order_OrderProduct) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order_OrderProduct.ProtoReflect.Descriptor instead.
+func (*Order_OrderProduct) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{1, 0}
+}
+
+func (x *Order_OrderProduct) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Order_OrderProduct) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Order_OrderProduct) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Order_OrderProduct) GetQuantity() uint64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Order_OrderProduct) GetPrice() *Money {
+	if x != nil {
+		return x.Price
+	}
+	return nil
+}
+
+type PostOrderRequest_OrderProduct struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     string                 `protobuf:"bytes,2,opt,name=productId,proto3" json:"productId,omitempty"`
+	Quantity      uint64                 `protobuf:"varint,3,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostOrderRequest_OrderProduct) Reset() {
+	*x = PostOrderRequest_OrderProduct{}
+	mi := &file_order_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostOrderRequest_OrderProduct) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostOrderRequest_OrderProduct) ProtoMessage() {}
+
+func (x *PostOrderRequest_OrderProduct) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostOrderRequest_OrderProduct.ProtoReflect.Descriptor instead.
+func (*PostOrderRequest_OrderProduct) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{2, 0}
+}
+
+func (x *PostOrderRequest_OrderProduct) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *PostOrderRequest_OrderProduct) GetQuantity() uint64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type ListOrdersForAccountsResponse_AccountOrders struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=accountId,proto3" json:"accountId,omitempty"`
+	Orders        []*Order               `protobuf:"bytes,2,rep,name=orders,proto3" json:"orders,omitempty"`
+	Cursors       []string               `protobuf:"bytes,3,rep,name=cursors,proto3" json:"cursors,omitempty"`
+	HasNextPage   bool                   `protobuf:"varint,4,opt,name=hasNextPage,proto3" json:"hasNextPage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersForAccountsResponse_AccountOrders) Reset() {
+	*x = ListOrdersForAccountsResponse_AccountOrders{}
+	mi := &file_order_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersForAccountsResponse_AccountOrders) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersForAccountsResponse_AccountOrders) ProtoMessage() {}
+
+func (x *ListOrdersForAccountsResponse_AccountOrders) ProtoReflect() protoreflect.Message {
+	mi := &file_order_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersForAccountsResponse_AccountOrders.ProtoReflect.Descriptor instead.
+func (*ListOrdersForAccountsResponse_AccountOrders) Descriptor() ([]byte, []int) {
+	return file_order_proto_rawDescGZIP(), []int{11, 0}
+}
+
+func (x *ListOrdersForAccountsResponse_AccountOrders) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *ListOrdersForAccountsResponse_AccountOrders) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *ListOrdersForAccountsResponse_AccountOrders) GetCursors() []string {
+	if x != nil {
+		return x.Cursors
+	}
+	return nil
+}
+
+func (x *ListOrdersForAccountsResponse_AccountOrders) GetHasNextPage() bool {
+	if x != nil {
+		return x.HasNextPage
+	}
+	return false
+}
+
+var File_order_proto protoreflect.FileDescriptor
+
+const file_order_proto_rawDesc = "" +
+	"\n" +
+	"\vorder.proto\x12\x05order\x1a\x1fgoogle/protobuf/timestamp.proto\";\n" +
+	"\x05Money\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x03R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\"\x90\x03\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x129\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x1c\n" +
+	"\taccountId\x18\x03 \x01(\tR\taccountId\x125\n" +
+	"\bProducts\x18\x05 \x03(\v2\x19.order.Order.OrderProductR\bProducts\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12,\n" +
+	"\n" +
+	"totalPrice\x18\a \x01(\v2\f.order.MoneyR\n" +
+	"totalPrice\x1a\x9a\x01\n" +
+	"\fOrderProduct\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1a\n" +
+	"\bquantity\x18\x05 \x01(\x04R\bquantity\x12\"\n" +
+	"\x05price\x18\x06 \x01(\v2\f.order.MoneyR\x05priceJ\x04\b\x04\x10\x05J\x04\b\x04\x10\x05\"\xe4\x01\n" +
+	"\x10PostOrderRequest\x12\x1c\n" +
+	"\tAccountId\x18\x02 \x01(\tR\tAccountId\x12@\n" +
+	"\bProducts\x18\x03 \x03(\v2$.order.PostOrderRequest.OrderProductR\bProducts\x12&\n" +
+	"\x0eidempotencyKey\x18\x04 \x01(\tR\x0eidempotencyKey\x1aH\n" +
+	"\fOrderProduct\x12\x1c\n" +
+	"\tproductId\x18\x02 \x01(\tR\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x03 \x01(\x04R\bquantity\"7\n" +
+	"\x11PostOrderResponse\x12\"\n" +
+	"\x05Order\x18\x01 \x01(\v2\f.order.OrderR\x05Order\"!\n" +
+	"\x0fGetOrderRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"6\n" +
+	"\x10GetOrderResponse\x12\"\n" +
+	"\x05order\x18\x01 \x01(\v2\f.order.OrderR\x05order\"9\n" +
+	"\x19GetOrderForAccountRequest\x12\x1c\n" +
+	"\taccountId\x18\x01 \x01(\tR\taccountId\"B\n" +
+	"\x1aGetOrderForAccountResponse\x12$\n" +
+	"\x06orders\x18\x01 \x03(\v2\f.order.OrderR\x06orders\"\xe3\x02\n" +
+	"\x11ListOrdersRequest\x12\x1c\n" +
+	"\taccountId\x18\x01 \x01(\tR\taccountId\x12\x14\n" +
+	"\x05first\x18\x02 \x01(\x04R\x05first\x12\x14\n" +
+	"\x05after\x18\x03 \x01(\tR\x05after\x12>\n" +
+	"\fcreatedAfter\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\fcreatedAfter\x12@\n" +
+	"\rcreatedBefore\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\rcreatedBefore\x12\x1a\n" +
+	"\bstatuses\x18\x06 \x03(\tR\bstatuses\x12(\n" +
+	"\bminTotal\x18\a \x01(\v2\f.order.MoneyR\bminTotal\x12(\n" +
+	"\bmaxTotal\x18\b \x01(\v2\f.order.MoneyR\bmaxTotal\x12\x12\n" +
+	"\x04sort\x18\t \x01(\tR\x04sort\"v\n" +
+	"\x12ListOrdersResponse\x12$\n" +
+	"\x06orders\x18\x01 \x03(\v2\f.order.OrderR\x06orders\x12\x18\n" +
+	"\acursors\x18\x02 \x03(\tR\acursors\x12 \n" +
+	"\vhasNextPage\x18\x03 \x01(\bR\vhasNextPage\"n\n" +
+	"\x1cListOrdersForAccountsRequest\x12\x1e\n" +
+	"\n" +
+	"accountIds\x18\x01 \x03(\tR\n" +
+	"accountIds\x12.\n" +
+	"\x05query\x18\x02 \x01(\v2\x18.order.ListOrdersRequestR\x05query\"\x81\x02\n" +
+	"\x1dListOrdersForAccountsResponse\x12N\n" +
+	"\baccounts\x18\x01 \x03(\v22.order.ListOrdersForAccountsResponse.AccountOrdersR\baccounts\x1a\x8f\x01\n" +
+	"\rAccountOrders\x12\x1c\n" +
+	"\taccountId\x18\x01 \x01(\tR\taccountId\x12$\n" +
+	"\x06orders\x18\x02 \x03(\v2\f.order.OrderR\x06orders\x12\x18\n" +
+	"\acursors\x18\x03 \x03(\tR\acursors\x12 \n" +
+	"\vhasNextPage\x18\x04 \x01(\bR\vhasNextPage\"L\n" +
+	"\x12WatchOrdersRequest\x12\x18\n" +
+	"\aorderId\x18\x01 \x01(\tR\aorderId\x12\x1c\n" +
+	"\taccountId\x18\x02 \x01(\tR\taccountId\"D\n" +
+	"\n" +
+	"OrderEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\"\n" +
+	"\x05order\x18\x02 \x01(\v2\f.order.OrderR\x05order\"B\n" +
+	"\x18UpdateOrderStatusRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\"?\n" +
+	"\x19UpdateOrderStatusResponse\x12\"\n" +
+	"\x05order\x18\x01 \x01(\v2\f.order.OrderR\x05order\"$\n" +
+	"\x12CancelOrderRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"9\n" +
+	"\x13CancelOrderResponse\x12\"\n" +
+	"\x05order\x18\x01 \x01(\v2\f.order.OrderR\x05order2\xea\x04\n" +
+	"\fOrderService\x12>\n" +
+	"\tPostOrder\x12\x17.order.PostOrderRequest\x1a\x18.order.PostOrderResponse\x12;\n" +
+	"\bGetOrder\x12\x16.order.GetOrderRequest\x1a\x17.order.GetOrderResponse\x12Y\n" +
+	"\x12GetOrderForAccount\x12 .order.GetOrderForAccountRequest\x1a!.order.GetOrderForAccountResponse\x12A\n" +
+	"\n" +
+	"ListOrders\x12\x18.order.ListOrdersRequest\x1a\x19.order.ListOrdersResponse\x12b\n" +
+	"\x15ListOrdersForAccounts\x12#.order.ListOrdersForAccountsRequest\x1a$.order.ListOrdersForAccountsResponse\x12V\n" +
+	"\x11UpdateOrderStatus\x12\x1f.order.UpdateOrderStatusRequest\x1a .order.UpdateOrderStatusResponse\x12D\n" +
+	"\vCancelOrder\x12\x19.order.CancelOrderRequest\x1a\x1a.order.CancelOrderResponse\x12=\n" +
+	"\vWatchOrders\x12\x19.order.WatchOrdersRequest\x1a\x11.order.OrderEvent0\x01B\x03Z\x01.b\x06proto3"
 
 var (
 	file_order_proto_rawDescOnce sync.Once
@@ -551,37 +1308,72 @@ func file_order_proto_rawDescGZIP() []byte {
 	return file_order_proto_rawDescData
 }
 
-var file_order_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_order_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
 var file_order_proto_goTypes = []any{
-	(*Order)(nil),                         // 0: order.Order
-	(*PostOrderRequest)(nil),              // 1: order.PostOrderRequest
-	(*PostOrderResponse)(nil),             // 2: order.PostOrderResponse
-	(*GetOrderRequest)(nil),               // 3: order.GetOrderRequest
-	(*GetOrderResponse)(nil),              // 4: order.GetOrderResponse
-	(*GetOrderForAccountRequest)(nil),     // 5: order.GetOrderForAccountRequest
-	(*GetOrderForAccountResponse)(nil),    // 6: order.GetOrderForAccountResponse
-	(*Order_OrderProduct)(nil),            // 7: order.Order.OrderProduct
-	(*PostOrderRequest_OrderProduct)(nil), // 8: order.PostOrderRequest.OrderProduct
-	(*timestamppb.Timestamp)(nil),         // 9: google.protobuf.Timestamp
+	(*Money)(nil),                                       // 0: order.Money
+	(*Order)(nil),                                       // 1: order.Order
+	(*PostOrderRequest)(nil),                            // 2: order.PostOrderRequest
+	(*PostOrderResponse)(nil),                           // 3: order.PostOrderResponse
+	(*GetOrderRequest)(nil),                             // 4: order.GetOrderRequest
+	(*GetOrderResponse)(nil),                            // 5: order.GetOrderResponse
+	(*GetOrderForAccountRequest)(nil),                   // 6: order.GetOrderForAccountRequest
+	(*GetOrderForAccountResponse)(nil),                  // 7: order.GetOrderForAccountResponse
+	(*ListOrdersRequest)(nil),                           // 8: order.ListOrdersRequest
+	(*ListOrdersResponse)(nil),                          // 9: order.ListOrdersResponse
+	(*ListOrdersForAccountsRequest)(nil),                // 10: order.ListOrdersForAccountsRequest
+	(*ListOrdersForAccountsResponse)(nil),               // 11: order.ListOrdersForAccountsResponse
+	(*WatchOrdersRequest)(nil),                          // 12: order.WatchOrdersRequest
+	(*OrderEvent)(nil),                                  // 13: order.OrderEvent
+	(*UpdateOrderStatusRequest)(nil),                    // 14: order.UpdateOrderStatusRequest
+	(*UpdateOrderStatusResponse)(nil),                   // 15: order.UpdateOrderStatusResponse
+	(*CancelOrderRequest)(nil),                          // 16: order.CancelOrderRequest
+	(*CancelOrderResponse)(nil),                         // 17: order.CancelOrderResponse
+	(*Order_OrderProduct)(nil),                          // 18: order.Order.OrderProduct
+	(*PostOrderRequest_OrderProduct)(nil),               // 19: order.PostOrderRequest.OrderProduct
+	(*ListOrdersForAccountsResponse_AccountOrders)(nil), // 20: order.ListOrdersForAccountsResponse.AccountOrders
+	(*timestamppb.Timestamp)(nil),                       // 21: google.protobuf.Timestamp
 }
 var file_order_proto_depIdxs = []int32{
-	9, // 0: order.Order.created_at:type_name -> google.protobuf.Timestamp
-	7, // 1: order.Order.Products:type_name -> order.Order.OrderProduct
-	8, // 2: order.PostOrderRequest.Products:type_name -> order.PostOrderRequest.OrderProduct
-	0, // 3: order.PostOrderResponse.Order:type_name -> order.Order
-	0, // 4: order.GetOrderResponse.order:type_name -> order.Order
-	0, // 5: order.GetOrderForAccountResponse.orders:type_name -> order.Order
-	1, // 6: order.OrderService.PostOrder:input_type -> order.PostOrderRequest
-	3, // 7: order.OrderService.GetOrder:input_type -> order.GetOrderRequest
-	5, // 8: order.OrderService.GetOrderForAccount:input_type -> order.GetOrderForAccountRequest
-	2, // 9: order.OrderService.PostOrder:output_type -> order.PostOrderResponse
-	4, // 10: order.OrderService.GetOrder:output_type -> order.GetOrderResponse
-	6, // 11: order.OrderService.GetOrderForAccount:output_type -> order.GetOrderForAccountResponse
-	9, // [9:12] is the sub-list for method output_type
-	6, // [6:9] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	21, // 0: order.Order.created_at:type_name -> google.protobuf.Timestamp
+	18, // 1: order.Order.Products:type_name -> order.Order.OrderProduct
+	0,  // 2: order.Order.totalPrice:type_name -> order.Money
+	19, // 3: order.PostOrderRequest.Products:type_name -> order.PostOrderRequest.OrderProduct
+	1,  // 4: order.PostOrderResponse.Order:type_name -> order.Order
+	1,  // 5: order.GetOrderResponse.order:type_name -> order.Order
+	1,  // 6: order.GetOrderForAccountResponse.orders:type_name -> order.Order
+	21, // 7: order.ListOrdersRequest.createdAfter:type_name -> google.protobuf.Timestamp
+	21, // 8: order.ListOrdersRequest.createdBefore:type_name -> google.protobuf.Timestamp
+	0,  // 9: order.ListOrdersRequest.minTotal:type_name -> order.Money
+	0,  // 10: order.ListOrdersRequest.maxTotal:type_name -> order.Money
+	1,  // 11: order.ListOrdersResponse.orders:type_name -> order.Order
+	8,  // 12: order.ListOrdersForAccountsRequest.query:type_name -> order.ListOrdersRequest
+	20, // 13: order.ListOrdersForAccountsResponse.accounts:type_name -> order.ListOrdersForAccountsResponse.AccountOrders
+	1,  // 14: order.OrderEvent.order:type_name -> order.Order
+	1,  // 15: order.UpdateOrderStatusResponse.order:type_name -> order.Order
+	1,  // 16: order.CancelOrderResponse.order:type_name -> order.Order
+	0,  // 17: order.Order.OrderProduct.price:type_name -> order.Money
+	1,  // 18: order.ListOrdersForAccountsResponse.AccountOrders.orders:type_name -> order.Order
+	2,  // 19: order.OrderService.PostOrder:input_type -> order.PostOrderRequest
+	4,  // 20: order.OrderService.GetOrder:input_type -> order.GetOrderRequest
+	6,  // 21: order.OrderService.GetOrderForAccount:input_type -> order.GetOrderForAccountRequest
+	8,  // 22: order.OrderService.ListOrders:input_type -> order.ListOrdersRequest
+	10, // 23: order.OrderService.ListOrdersForAccounts:input_type -> order.ListOrdersForAccountsRequest
+	14, // 24: order.OrderService.UpdateOrderStatus:input_type -> order.UpdateOrderStatusRequest
+	16, // 25: order.OrderService.CancelOrder:input_type -> order.CancelOrderRequest
+	12, // 26: order.OrderService.WatchOrders:input_type -> order.WatchOrdersRequest
+	3,  // 27: order.OrderService.PostOrder:output_type -> order.PostOrderResponse
+	5,  // 28: order.OrderService.GetOrder:output_type -> order.GetOrderResponse
+	7,  // 29: order.OrderService.GetOrderForAccount:output_type -> order.GetOrderForAccountResponse
+	9,  // 30: order.OrderService.ListOrders:output_type -> order.ListOrdersResponse
+	11, // 31: order.OrderService.ListOrdersForAccounts:output_type -> order.ListOrdersForAccountsResponse
+	15, // 32: order.OrderService.UpdateOrderStatus:output_type -> order.UpdateOrderStatusResponse
+	17, // 33: order.OrderService.CancelOrder:output_type -> order.CancelOrderResponse
+	13, // 34: order.OrderService.WatchOrders:output_type -> order.OrderEvent
+	27, // [27:35] is the sub-list for method output_type
+	19, // [19:27] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_order_proto_init() }
@@ -595,7 +1387,7 @@ func file_order_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_order_proto_rawDesc), len(file_order_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   21,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,93 @@
+package order
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/catalog"
+)
+
+// ProductCacheConfig bounds the cache of catalog products kept by the order
+// server. A zero TTL or size disables the cache.
+type ProductCacheConfig struct {
+	TTL        time.Duration
+	MaxEntries int
+}
+
+var DefaultProductCacheConfig = ProductCacheConfig{
+	TTL:        30 * time.Second,
+	MaxEntries: 1000,
+}
+
+// productCache keeps recently fetched catalog products for a short time, so
+// reading many orders does not ask the catalog for the same product again.
+// Entries expire after the TTL; when the cache is full the least recently
+// used entry is evicted. A nil cache never hits.
+type productCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	entries map[string]*list.Element
+	// lru holds *cacheEntry values, most recently used first
+	lru *list.List
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	product   catalog.Product
+	expiresAt time.Time
+}
+
+func newProductCache(cfg ProductCacheConfig) *productCache {
+	if cfg.TTL <= 0 || cfg.MaxEntries <= 0 {
+		return nil
+	}
+	return &productCache{
+		ttl:     cfg.TTL,
+		max:     cfg.MaxEntries,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *productCache) get(id string) (catalog.Product, bool) {
+	if c == nil {
+		return catalog.Product{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[id]
+	if !ok {
+		return catalog.Product{}, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.lru.Remove(el)
+		delete(c.entries, id)
+		return catalog.Product{}, false
+	}
+	c.lru.MoveToFront(el)
+	return entry.product, true
+}
+
+func (c *productCache) put(p catalog.Product) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt := c.now().Add(c.ttl)
+	if el, ok := c.entries[p.ID]; ok {
+		el.Value = &cacheEntry{product: p, expiresAt: expiresAt}
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[p.ID] = c.lru.PushFront(&cacheEntry{product: p, expiresAt: expiresAt})
+	for c.lru.Len() > c.max {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).product.ID)
+	}
+}
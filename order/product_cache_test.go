@@ -0,0 +1,55 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/catalog"
+)
+
+func TestProductCacheExpiry(t *testing.T) {
+	c := newProductCache(ProductCacheConfig{TTL: time.Minute, MaxEntries: 10})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.put(catalog.Product{ID: "a", Name: "lamp"})
+	if p, ok := c.get("a"); !ok || p.Name != "lamp" {
+		t.Fatalf("get(a) = %+v, %v", p, ok)
+	}
+	now = now.Add(time.Minute)
+	if _, ok := c.get("a"); ok {
+		t.Error("entry still cached after its TTL")
+	}
+	if c.lru.Len() != 0 || len(c.entries) != 0 {
+		t.Errorf("expired entry not removed: %d in list, %d in map", c.lru.Len(), len(c.entries))
+	}
+}
+
+func TestProductCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newProductCache(ProductCacheConfig{TTL: time.Minute, MaxEntries: 2})
+
+	c.put(catalog.Product{ID: "a"})
+	c.put(catalog.Product{ID: "b"})
+	c.get("a")
+	c.put(catalog.Product{ID: "c"})
+
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used entry b was not evicted")
+	}
+	for _, id := range []string{"a", "c"} {
+		if _, ok := c.get(id); !ok {
+			t.Errorf("entry %s was evicted", id)
+		}
+	}
+}
+
+func TestProductCacheDisabled(t *testing.T) {
+	c := newProductCache(ProductCacheConfig{TTL: 0, MaxEntries: 10})
+	if c != nil {
+		t.Fatal("zero TTL should disable the cache")
+	}
+	c.put(catalog.Product{ID: "a"})
+	if _, ok := c.get("a"); ok {
+		t.Error("disabled cache returned an entry")
+	}
+}
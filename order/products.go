@@ -0,0 +1,40 @@
+package order
+
+import (
+	"github.com/pawan-sharma-12/go_microservices/catalog"
+)
+
+// MergeProducts combines order lines that reference the same product by
+// summing their quantities. The first occurrence of each product keeps its
+// position.
+func MergeProducts(products []OrderProduct) []OrderProduct {
+	merged := make([]OrderProduct, 0, len(products))
+	index := make(map[string]int, len(products))
+	for _, p := range products {
+		if i, ok := index[p.ID]; ok {
+			merged[i].Quantity += p.Quantity
+			continue
+		}
+		index[p.ID] = len(merged)
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// MissingProductIDs returns the IDs of order lines the catalog did not return
+// or returned as deleted
+func MissingProductIDs(products []OrderProduct, catalogProducts []catalog.Product) []string {
+	found := make(map[string]bool, len(catalogProducts))
+	for _, cp := range catalogProducts {
+		if !cp.Deleted {
+			found[cp.ID] = true
+		}
+	}
+	missing := []string{}
+	for _, p := range products {
+		if !found[p.ID] {
+			missing = append(missing, p.ID)
+		}
+	}
+	return missing
+}
@@ -3,15 +3,32 @@ package order
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/pawan-sharma-12/go_microservices/money"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
+)
+
+var (
+	ErrNotFound                = errors.New("entity not found")
+	ErrDuplicateIdempotencyKey = errors.New("duplicate idempotency key")
 )
 
 type Repository interface {
 	Close()
+	// Ping checks that the database answers
+	Ping(ctx context.Context) error
 	PutOrder(ctx context.Context, order Order) error
+	GetOrder(ctx context.Context, id string) (*Order, error)
+	GetOrderByIdempotencyKey(ctx context.Context, accountID string, key string) (*Order, error)
 	GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error)
+	ListOrders(ctx context.Context, filter OrderFilter, sortBy OrderSort, after *Order, first uint64) ([]Order, error)
+	ListOrdersForAccounts(ctx context.Context, accountIDs []string, filter OrderFilter, sortBy OrderSort, first uint64) ([]Order, error)
+	UpdateOrderStatus(ctx context.Context, id string, from OrderStatus, to OrderStatus, at time.Time) error
 }
 
 type postgresRepository struct {
@@ -19,7 +36,7 @@ type postgresRepository struct {
 }
 
 func NewPostgresRepository(url string) (Repository, error) {
-	db, err := sql.Open("postgres", url)
+	db, err := tracing.OpenPostgres(url)
 	if err != nil {
 		return nil, err
 	}
@@ -36,6 +53,10 @@ func (r *postgresRepository) Close() {
 	r.db.Close()
 }
 
+func (r *postgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
 func (r *postgresRepository) PutOrder(ctx context.Context, order Order) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -45,11 +66,33 @@ func (r *postgresRepository) PutOrder(ctx context.Context, order Order) error {
 	// Insert order
 	_, err = tx.ExecContext(
 		ctx,
-		"INSERT INTO orders (id, created_at, account_id, total_price) VALUES ($1, $2, $3, $4)",
+		`INSERT INTO orders (id, created_at, account_id, total_amount, currency, status, idempotency_key, request_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''))`,
 		order.ID,
 		order.CreatedAt,
 		order.AccountID,
-		order.TotalPrice,
+		order.TotalPrice.Amount,
+		order.TotalPrice.Currency,
+		order.Status,
+		order.IdempotencyKey,
+		order.RequestHash,
+	)
+	if err != nil {
+		tx.Rollback()
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "orders_account_idempotency_key_key" {
+			return ErrDuplicateIdempotencyKey
+		}
+		return err
+	}
+
+	// Record the initial status
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO order_status_history (order_id, from_status, to_status, changed_at) VALUES ($1, NULL, $2, $3)",
+		order.ID,
+		order.Status,
+		order.CreatedAt,
 	)
 	if err != nil {
 		tx.Rollback()
@@ -57,14 +100,14 @@ func (r *postgresRepository) PutOrder(ctx context.Context, order Order) error {
 	}
 
 	// Prepare COPY for order_products
-	stmt, err := tx.Prepare(pq.CopyIn("order_products", "order_id", "product_id", "quantity"))
+	stmt, err := tx.Prepare(pq.CopyIn("order_products", "order_id", "product_id", "quantity", "name", "description", "price_amount", "currency"))
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
 	for _, product := range order.Products {
-		_, err = stmt.Exec(order.ID, product.ID, product.Quantity)
+		_, err = stmt.Exec(order.ID, product.ID, product.Quantity, product.Name, product.Description, product.Price.Amount, product.Price.Currency)
 		if err != nil {
 			stmt.Close()
 			tx.Rollback()
@@ -83,14 +126,60 @@ func (r *postgresRepository) PutOrder(ctx context.Context, order Order) error {
 	return tx.Commit()
 }
 
+func (r *postgresRepository) GetOrder(ctx context.Context, id string) (*Order, error) {
+	return r.getOrder(ctx, "o.id = $1", id)
+}
+
+func (r *postgresRepository) GetOrderByIdempotencyKey(ctx context.Context, accountID string, key string) (*Order, error) {
+	return r.getOrder(ctx, "o.account_id = $1 AND o.idempotency_key = $2", accountID, key)
+}
+
+// getOrder loads the single order matching where, along with its products
+func (r *postgresRepository) getOrder(ctx context.Context, where string, args ...any) (*Order, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT o.id, o.created_at, o.account_id, o.total_amount, o.currency, o.status,
+			COALESCE(o.idempotency_key, ''), COALESCE(o.request_hash, ''),
+			op.product_id, op.quantity, op.name, op.description, op.price_amount, op.currency
+		FROM orders o
+		JOIN order_products op ON o.id = op.order_id
+		WHERE `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var order *Order
+	for rows.Next() {
+		var product OrderProduct
+		if order == nil {
+			order = &Order{Products: []OrderProduct{}}
+		}
+		if err := rows.Scan(&order.ID, &order.CreatedAt, &order.AccountID, &order.TotalPrice.Amount, &order.TotalPrice.Currency, &order.Status, &order.IdempotencyKey, &order.RequestHash, &product.ID, &product.Quantity, &product.Name, &product.Description, &product.Price.Amount, &product.Price.Currency); err != nil {
+			return nil, err
+		}
+		order.Products = append(order.Products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, ErrNotFound
+	}
+
+	return order, nil
+}
+
 func (r *postgresRepository) GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error) {
 	rows, err := r.db.QueryContext(
 		ctx,
-		`SELECT o.id, o.created_at, o.account_id, o.total_price, op.product_id, op.quantity
+		`SELECT o.id, o.created_at, o.account_id, o.total_amount, o.currency, o.status, op.product_id, op.quantity, op.name, op.description, op.price_amount, op.currency
 		FROM orders o
 		JOIN order_products op ON o.id = op.order_id
 		WHERE o.account_id = $1
-		ORDER BY o.created_at`,
+		ORDER BY o.created_at, o.id`,
 		accountID,
 	)
 	if err != nil {
@@ -98,43 +187,248 @@ func (r *postgresRepository) GetOrdersForAccount(ctx context.Context, accountID
 	}
 	defer rows.Close()
 
-	ordersMap := make(map[string]*Order)
+	// Rows arrive in order; the slice keeps that order, the map only finds
+	// the order a product row belongs to
+	orders := []Order{}
+	index := make(map[string]int)
 
 	for rows.Next() {
 		var (
 			orderID      string
 			createdAt    time.Time
 			accountIDRow string
-			totalPrice   float64
-			productID    string
-			quantity     uint64
+			totalPrice   money.Money
+			status       OrderStatus
+			product      OrderProduct
 		)
-		if err := rows.Scan(&orderID, &createdAt, &accountIDRow, &totalPrice, &productID, &quantity); err != nil {
+		if err := rows.Scan(&orderID, &createdAt, &accountIDRow, &totalPrice.Amount, &totalPrice.Currency, &status, &product.ID, &product.Quantity, &product.Name, &product.Description, &product.Price.Amount, &product.Price.Currency); err != nil {
 			return nil, err
 		}
 
-		order, exists := ordersMap[orderID]
+		i, exists := index[orderID]
 		if !exists {
-			order = &Order{
+			i = len(orders)
+			index[orderID] = i
+			orders = append(orders, Order{
 				ID:         orderID,
 				CreatedAt:  createdAt,
 				AccountID:  accountIDRow,
 				TotalPrice: totalPrice,
+				Status:     status,
 				Products:   []OrderProduct{},
-			}
-			ordersMap[orderID] = order
+			})
 		}
 
-		order.Products = append(order.Products, OrderProduct{
-			ID: productID,
-			Quantity:  quantity,
-		})
+		orders[i].Products = append(orders[i].Products, product)
 	}
-
-	orders := make([]Order, 0, len(ordersMap))
-	for _, o := range ordersMap {
-		orders = append(orders, *o)
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	return orders, nil
 }
+
+// ListOrders returns up to first orders matching filter that come after the
+// given order in sortBy order
+func (r *postgresRepository) ListOrders(ctx context.Context, filter OrderFilter, sortBy OrderSort, after *Order, first uint64) ([]Order, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where := filterConditions(filter, arg)
+
+	key, direction := sortColumn(sortBy)
+	if after != nil {
+		var afterKey any = after.CreatedAt
+		if sortBy.byTotal() {
+			afterKey = after.TotalPrice.Amount
+		}
+		op := ">"
+		if sortBy.descending() {
+			op = "<"
+		}
+		// Row comparison continues right after the last order, ties included
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", key, op, arg(afterKey), arg(after.ID)))
+	}
+
+	query := "SELECT id, created_at, account_id, total_amount, currency, status FROM orders"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", key, direction, direction, arg(first))
+
+	return r.queryOrders(ctx, query, args...)
+}
+
+// ListOrdersForAccounts returns, for each account, up to first of its orders
+// matching filter in sortBy order. filter.AccountID is ignored. The orders of
+// one account are adjacent and in sortBy order.
+func (r *postgresRepository) ListOrdersForAccounts(ctx context.Context, accountIDs []string, filter OrderFilter, sortBy OrderSort, first uint64) ([]Order, error) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	filter.AccountID = ""
+	where := append([]string{"account_id = ANY(" + arg(pq.Array(accountIDs)) + ")"}, filterConditions(filter, arg)...)
+	key, direction := sortColumn(sortBy)
+
+	// Rank the orders of each account and keep the first few of every one
+	query := fmt.Sprintf(
+		`SELECT id, created_at, account_id, total_amount, currency, status
+		FROM (
+			SELECT id, created_at, account_id, total_amount, currency, status,
+				ROW_NUMBER() OVER (PARTITION BY account_id ORDER BY %s %s, id %s) AS row_num
+			FROM orders
+			WHERE %s
+		) ranked
+		WHERE row_num <= %s
+		ORDER BY account_id, row_num`,
+		key, direction, direction, strings.Join(where, " AND "), arg(first),
+	)
+
+	return r.queryOrders(ctx, query, args...)
+}
+
+// Helper: WHERE conditions selecting the orders that pass filter. arg adds a
+// query argument and returns its placeholder.
+func filterConditions(filter OrderFilter, arg func(any) string) []string {
+	var where []string
+	if filter.AccountID != "" {
+		where = append(where, "account_id = "+arg(filter.AccountID))
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where = append(where, "created_at >= "+arg(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where = append(where, "created_at < "+arg(filter.CreatedBefore))
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		where = append(where, "status = ANY("+arg(pq.Array(statuses))+")")
+	}
+	if filter.MinTotal != nil {
+		where = append(where, "currency = "+arg(filter.MinTotal.Currency), "total_amount >= "+arg(filter.MinTotal.Amount))
+	}
+	if filter.MaxTotal != nil {
+		where = append(where, "currency = "+arg(filter.MaxTotal.Currency), "total_amount <= "+arg(filter.MaxTotal.Amount))
+	}
+	return where
+}
+
+// Helper: the column and direction orders are sorted by
+func sortColumn(sortBy OrderSort) (key string, direction string) {
+	key, direction = "created_at", "ASC"
+	if sortBy.byTotal() {
+		key = "total_amount"
+	}
+	if sortBy.descending() {
+		direction = "DESC"
+	}
+	return key, direction
+}
+
+// Helper: run a query selecting id, created_at, account_id, total_amount,
+// currency and status, and load the products of the orders it returns
+func (r *postgresRepository) queryOrders(ctx context.Context, query string, args ...any) ([]Order, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []Order{}
+	index := map[string]int{}
+	ids := []string{}
+	for rows.Next() {
+		order := Order{Products: []OrderProduct{}}
+		if err := rows.Scan(&order.ID, &order.CreatedAt, &order.AccountID, &order.TotalPrice.Amount, &order.TotalPrice.Currency, &order.Status); err != nil {
+			return nil, err
+		}
+		index[order.ID] = len(orders)
+		ids = append(ids, order.ID)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	// Products of all orders in one query
+	productRows, err := r.db.QueryContext(
+		ctx,
+		`SELECT order_id, product_id, quantity, name, description, price_amount, currency
+		FROM order_products
+		WHERE order_id = ANY($1)`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer productRows.Close()
+
+	for productRows.Next() {
+		var (
+			orderID string
+			product OrderProduct
+		)
+		if err := productRows.Scan(&orderID, &product.ID, &product.Quantity, &product.Name, &product.Description, &product.Price.Amount, &product.Price.Currency); err != nil {
+			return nil, err
+		}
+		i := index[orderID]
+		orders[i].Products = append(orders[i].Products, product)
+	}
+	return orders, productRows.Err()
+}
+
+// UpdateOrderStatus moves an order from one status to another and records the
+// transition. It fails with ErrInvalidTransition if the order is no longer in
+// the expected status, so concurrent updates cannot skip the state machine.
+func (r *postgresRepository) UpdateOrderStatus(ctx context.Context, id string, from OrderStatus, to OrderStatus, at time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE orders SET status = $1 WHERE id = $2 AND status = $3",
+		to,
+		id,
+		from,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if n == 0 {
+		tx.Rollback()
+		return ErrInvalidTransition
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO order_status_history (order_id, from_status, to_status, changed_at) VALUES ($1, $2, $3, $4)",
+		id,
+		from,
+		to,
+		at,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
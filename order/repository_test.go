@@ -0,0 +1,361 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pawan-sharma-12/go_microservices/money"
+	"github.com/segmentio/ksuid"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		return NewMemoryRepository()
+	})
+}
+
+// TestPostgresRepository runs the conformance suite against a real database.
+// Point ORDER_TEST_DATABASE_URL at a disposable database with up.sql applied.
+func TestPostgresRepository(t *testing.T) {
+	url := os.Getenv("ORDER_TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("ORDER_TEST_DATABASE_URL not set")
+	}
+	testRepository(t, func(t *testing.T) Repository {
+		repo, err := NewPostgresRepository(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	})
+}
+
+// testRepository checks the behaviour every Repository implementation must
+// share. Each test uses a fresh account, so the backend may already hold
+// other data.
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+	newOrder := func(accountID string, createdAt time.Time, key string) Order {
+		price := money.New(250, "USD")
+		return Order{
+			ID:         ksuid.New().String(),
+			CreatedAt:  createdAt.UTC().Truncate(time.Microsecond),
+			AccountID:  accountID,
+			TotalPrice: price.Mul(3),
+			Status:     StatusPending,
+			Products: []OrderProduct{
+				{ID: ksuid.New().String(), Name: "pen", Description: "blue", Price: price, Quantity: 2},
+				{ID: ksuid.New().String(), Name: "ink", Description: "black", Price: price, Quantity: 1},
+			},
+			IdempotencyKey: key,
+		}
+	}
+	put := func(t *testing.T, repo Repository, o Order) {
+		t.Helper()
+		if err := repo.PutOrder(ctx, o); err != nil {
+			t.Fatalf("PutOrder: %v", err)
+		}
+	}
+	assertOrder := func(t *testing.T, got *Order, want Order) {
+		t.Helper()
+		if got.ID != want.ID || !got.CreatedAt.Equal(want.CreatedAt) || got.AccountID != want.AccountID ||
+			got.TotalPrice != want.TotalPrice || got.Status != want.Status {
+			t.Errorf("got %+v, want %+v", *got, want)
+		}
+		products := append([]OrderProduct{}, got.Products...)
+		wantProducts := append([]OrderProduct{}, want.Products...)
+		sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+		sort.Slice(wantProducts, func(i, j int) bool { return wantProducts[i].ID < wantProducts[j].ID })
+		if len(products) != len(wantProducts) {
+			t.Fatalf("got %d products, want %d", len(products), len(wantProducts))
+		}
+		for i := range products {
+			if products[i] != wantProducts[i] {
+				t.Errorf("product %d: got %+v, want %+v", i, products[i], wantProducts[i])
+			}
+		}
+	}
+
+	t.Run("PutAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		o := newOrder(ksuid.New().String(), time.Now(), "")
+		put(t, repo, o)
+
+		got, err := repo.GetOrder(ctx, o.ID)
+		if err != nil {
+			t.Fatalf("GetOrder: %v", err)
+		}
+		assertOrder(t, got, o)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		id := ksuid.New().String()
+
+		if _, err := repo.GetOrder(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetOrder: got %v, want ErrNotFound", err)
+		}
+		if _, err := repo.GetOrderByIdempotencyKey(ctx, id, "key"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetOrderByIdempotencyKey: got %v, want ErrNotFound", err)
+		}
+		orders, err := repo.GetOrdersForAccount(ctx, id)
+		if err != nil || len(orders) != 0 {
+			t.Errorf("GetOrdersForAccount: got %v, %d orders", err, len(orders))
+		}
+	})
+
+	t.Run("IdempotencyKey", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		accountID := ksuid.New().String()
+		o := newOrder(accountID, time.Now(), "key-1")
+		o.RequestHash = "0123456789012345678901234567890123456789012345678901234567890123"
+		put(t, repo, o)
+
+		got, err := repo.GetOrderByIdempotencyKey(ctx, accountID, "key-1")
+		if err != nil {
+			t.Fatalf("GetOrderByIdempotencyKey: %v", err)
+		}
+		assertOrder(t, got, o)
+		if got.IdempotencyKey != o.IdempotencyKey || got.RequestHash != o.RequestHash {
+			t.Errorf("got key %q hash %q, want %q %q", got.IdempotencyKey, got.RequestHash, o.IdempotencyKey, o.RequestHash)
+		}
+
+		if err := repo.PutOrder(ctx, newOrder(accountID, time.Now(), "key-1")); !errors.Is(err, ErrDuplicateIdempotencyKey) {
+			t.Errorf("same key, same account: got %v, want ErrDuplicateIdempotencyKey", err)
+		}
+		put(t, repo, newOrder(ksuid.New().String(), time.Now(), "key-1"))
+		put(t, repo, newOrder(accountID, time.Now(), ""))
+		put(t, repo, newOrder(accountID, time.Now(), ""))
+	})
+
+	t.Run("OrdersForAccount", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		accountID := ksuid.New().String()
+		now := time.Now()
+		want := map[string]Order{}
+		for i := 0; i < 3; i++ {
+			o := newOrder(accountID, now.Add(time.Duration(i)*time.Minute), "")
+			put(t, repo, o)
+			want[o.ID] = o
+		}
+		put(t, repo, newOrder(ksuid.New().String(), now, ""))
+
+		orders, err := repo.GetOrdersForAccount(ctx, accountID)
+		if err != nil {
+			t.Fatalf("GetOrdersForAccount: %v", err)
+		}
+		if len(orders) != len(want) {
+			t.Fatalf("got %d orders, want %d", len(orders), len(want))
+		}
+		for _, o := range orders {
+			w, ok := want[o.ID]
+			if !ok {
+				t.Errorf("unexpected order %s", o.ID)
+				continue
+			}
+			assertOrder(t, &o, w)
+		}
+	})
+
+	t.Run("ListOrders", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		accountID := ksuid.New().String()
+		now := time.Now().Truncate(time.Second)
+		// Two orders share a creation time and a total, so ties are exercised
+		specs := []struct {
+			offset time.Duration
+			amount int64
+			status OrderStatus
+		}{
+			{0, 500, StatusPending},
+			{time.Minute, 300, StatusPaid},
+			{time.Minute, 500, StatusCancelled},
+			{2 * time.Minute, 100, StatusPending},
+		}
+		var all []Order
+		for _, spec := range specs {
+			o := newOrder(accountID, now.Add(spec.offset), "")
+			o.TotalPrice = money.New(spec.amount, "USD")
+			o.Status = spec.status
+			put(t, repo, o)
+			all = append(all, o)
+		}
+		other := newOrder(accountID, now, "")
+		other.TotalPrice = money.New(400, "EUR")
+		put(t, repo, other)
+		put(t, repo, newOrder(ksuid.New().String(), now, ""))
+
+		usd := func(amount int64) *money.Money {
+			m := money.New(amount, "USD")
+			return &m
+		}
+		filters := map[string]OrderFilter{
+			"account":  {AccountID: accountID},
+			"after":    {AccountID: accountID, CreatedAfter: now.Add(time.Minute)},
+			"before":   {AccountID: accountID, CreatedBefore: now.Add(time.Minute)},
+			"statuses": {AccountID: accountID, Statuses: []OrderStatus{StatusPending, StatusCancelled}},
+			"total":    {AccountID: accountID, MinTotal: usd(300), MaxTotal: usd(500)},
+		}
+		candidates := append([]Order{other}, all...)
+		for name, filter := range filters {
+			for _, sortBy := range []OrderSort{SortCreatedAtDesc, SortCreatedAtAsc, SortTotalDesc, SortTotalAsc} {
+				var want []Order
+				for _, o := range candidates {
+					if filter.matches(o) {
+						want = append(want, o)
+					}
+				}
+				slices.SortFunc(want, sortBy.compare)
+
+				// Page one order at a time to check every cursor position
+				var got []Order
+				var after *Order
+				for len(got) <= len(want) {
+					page, err := repo.ListOrders(ctx, filter, sortBy, after, 1)
+					if err != nil {
+						t.Fatalf("%s/%s: ListOrders: %v", name, sortBy, err)
+					}
+					if len(page) == 0 {
+						break
+					}
+					got = append(got, page[0])
+					after = &page[0]
+				}
+				if len(got) != len(want) {
+					t.Errorf("%s/%s: got %d orders, want %d", name, sortBy, len(got), len(want))
+					continue
+				}
+				for i := range want {
+					assertOrder(t, &got[i], want[i])
+				}
+			}
+		}
+	})
+
+	t.Run("ListOrdersForAccounts", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		now := time.Now().Truncate(time.Second)
+		accounts := []string{ksuid.New().String(), ksuid.New().String(), ksuid.New().String()}
+		// The first account has three orders, the second one, the third none
+		want := map[string][]Order{}
+		for i, n := range []int{3, 1} {
+			for j := 0; j < n; j++ {
+				o := newOrder(accounts[i], now.Add(time.Duration(j)*time.Minute), "")
+				put(t, repo, o)
+				want[accounts[i]] = append(want[accounts[i]], o)
+			}
+		}
+		put(t, repo, newOrder(ksuid.New().String(), now, ""))
+
+		got, err := repo.ListOrdersForAccounts(ctx, accounts, OrderFilter{}, SortCreatedAtDesc, 2)
+		if err != nil {
+			t.Fatalf("ListOrdersForAccounts: %v", err)
+		}
+		byAccount := map[string][]Order{}
+		for _, o := range got {
+			byAccount[o.AccountID] = append(byAccount[o.AccountID], o)
+		}
+		if len(byAccount) != 2 {
+			t.Fatalf("got orders of %d accounts, want 2", len(byAccount))
+		}
+		for accountID, orders := range want {
+			slices.SortFunc(orders, SortCreatedAtDesc.compare)
+			if len(orders) > 2 {
+				orders = orders[:2]
+			}
+			if len(byAccount[accountID]) != len(orders) {
+				t.Errorf("account %s: got %d orders, want %d", accountID, len(byAccount[accountID]), len(orders))
+				continue
+			}
+			for i := range orders {
+				assertOrder(t, &byAccount[accountID][i], orders[i])
+			}
+		}
+	})
+
+	t.Run("OrdersForAccountOrder", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		accountID := ksuid.New().String()
+		now := time.Now()
+		var ids []string
+		for i := 5; i > 0; i-- {
+			o := newOrder(accountID, now.Add(-time.Duration(i)*time.Minute), "")
+			put(t, repo, o)
+			ids = append(ids, o.ID)
+		}
+
+		orders, err := repo.GetOrdersForAccount(ctx, accountID)
+		if err != nil {
+			t.Fatalf("GetOrdersForAccount: %v", err)
+		}
+		if len(orders) != len(ids) {
+			t.Fatalf("got %d orders, want %d", len(orders), len(ids))
+		}
+		for i, o := range orders {
+			if o.ID != ids[i] {
+				t.Errorf("orders[%d] = %s, want %s (oldest first)", i, o.ID, ids[i])
+			}
+		}
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		repo := newRepo(t)
+		defer repo.Close()
+		o := newOrder(ksuid.New().String(), time.Now(), "")
+		put(t, repo, o)
+
+		if err := repo.UpdateOrderStatus(ctx, o.ID, StatusPending, StatusPaid, time.Now()); err != nil {
+			t.Fatalf("UpdateOrderStatus: %v", err)
+		}
+		got, err := repo.GetOrder(ctx, o.ID)
+		if err != nil {
+			t.Fatalf("GetOrder: %v", err)
+		}
+		if got.Status != StatusPaid {
+			t.Errorf("status = %s, want %s", got.Status, StatusPaid)
+		}
+
+		// A stale from status must not overwrite a concurrent change
+		if err := repo.UpdateOrderStatus(ctx, o.ID, StatusPending, StatusCancelled, time.Now()); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("stale update: got %v, want ErrInvalidTransition", err)
+		}
+		if err := repo.UpdateOrderStatus(ctx, ksuid.New().String(), StatusPending, StatusPaid, time.Now()); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("unknown order: got %v, want ErrInvalidTransition", err)
+		}
+	})
+}
+
+// TestMigrationMinorUnits checks that the money migration scales legacy
+// amounts with the exponents of money.Exponent
+func TestMigrationMinorUnits(t *testing.T) {
+	when := regexp.MustCompile(`WHEN '([A-Z]{3})' THEN (\d)`)
+	for _, file := range []string{"up.sql", "migrations/4_money_minor_units.up.sql"} {
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches := when.FindAllStringSubmatch(string(sql), -1)
+		if len(matches) == 0 {
+			t.Errorf("%s: no minor units found", file)
+		}
+		for _, m := range matches {
+			if e, _ := strconv.Atoi(m[2]); e != money.Exponent(m[1]) {
+				t.Errorf("%s: %s has exponent %d, money.Exponent says %d", file, m[1], e, money.Exponent(m[1]))
+			}
+		}
+	}
+}
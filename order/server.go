@@ -6,12 +6,24 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/avast/retry-go"
 	"github.com/pawan-sharma-12/go_microservices/account"
+	"github.com/pawan-sharma-12/go_microservices/auth"
 	"github.com/pawan-sharma-12/go_microservices/catalog"
+	"github.com/pawan-sharma-12/go_microservices/cursor"
+	"github.com/pawan-sharma-12/go_microservices/graceful"
+	"github.com/pawan-sharma-12/go_microservices/health"
+	"github.com/pawan-sharma-12/go_microservices/money"
 	"github.com/pawan-sharma-12/go_microservices/order/pb"
+	"github.com/pawan-sharma-12/go_microservices/tracing"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -20,55 +32,124 @@ type grpcServer struct {
 	service       Service
 	accountClient *account.Client
 	catalogClient *catalog.Client
+	products      *productCache
+	// shutdown is done when the server starts draining, which ends the
+	// WatchOrders streams that would otherwise hold it up
+	shutdown context.Context
 	pb.UnimplementedOrderServiceServer
 }
 
-// ListenGRPC starts the gRPC server
-func ListenGRPC(s Service, accountURL, catalogURL string, port int) error {
+// productBatchSize caps the IDs sent to the catalog in one GetProducts call
+const productBatchSize = 100
+
+// accessPolicy says which callers may use each RPC. Everything goes through
+// the gateway, except the account service checking for open orders. Orders
+// are placed and cancelled for their owner or an admin, checked by the
+// handlers; only admins change their status.
+var accessPolicy = auth.Policy{
+	pb.OrderService_PostOrder_FullMethodName:             {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleUser, auth.RoleAdmin}},
+	pb.OrderService_GetOrder_FullMethodName:              {Services: []string{auth.ServiceGateway}},
+	pb.OrderService_GetOrderForAccount_FullMethodName:    {Services: []string{auth.ServiceGateway}},
+	pb.OrderService_ListOrders_FullMethodName:            {Services: []string{auth.ServiceGateway, auth.ServiceAccount}},
+	pb.OrderService_ListOrdersForAccounts_FullMethodName: {Services: []string{auth.ServiceGateway}},
+	pb.OrderService_UpdateOrderStatus_FullMethodName:     {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleAdmin}},
+	pb.OrderService_CancelOrder_FullMethodName:           {Services: []string{auth.ServiceGateway}, Roles: []string{auth.RoleUser, auth.RoleAdmin}},
+	pb.OrderService_WatchOrders_FullMethodName:           {Services: []string{auth.ServiceGateway}},
+}
+
+// ListenGRPC serves orders until ctx is done, then drains the server and
+// closes its clients. Catalog products looked up for orders are cached as
+// configured by cache. authn signs the calls to the other services and
+// checks incoming ones against accessPolicy.
+func ListenGRPC(ctx context.Context, s Service, accountURL, catalogURL string, port int, cache ProductCacheConfig, authn *auth.Authenticator, checks health.Checks) error {
 	log.Printf("🔗 Connecting to Account service at: %s", accountURL)
-	accountClient, err := account.NewClient(accountURL)
+	accountClient, err := account.NewClient(accountURL, authn)
 	if err != nil {
 		log.Printf("❌ Failed to connect to Account service: %v", err)
 		return err
 	}
 	log.Printf("🔗 Connecting to Catalog service at: %s", catalogURL)
-	catalogClient, err := catalog.NewClient(catalogURL)
+	catalogClient, err := catalog.NewClient(catalogURL, authn)
 	if err != nil {
 		log.Printf("❌ Failed to connect to Catalog service: %v", err)
 		accountClient.Close()
 		return err
 	}
 
+	defer accountClient.Close()
+	defer catalogClient.Close()
+
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
-		accountClient.Close()
-		catalogClient.Close()
 		return err
 	}
 
-	grpcSrv := grpc.NewServer()
+	grpcSrv := grpc.NewServer(append(authn.ServerOptions(accessPolicy), tracing.ServerOption())...)
 	pb.RegisterOrderServiceServer(grpcSrv, &grpcServer{
 		service:       s,
 		accountClient: accountClient,
 		catalogClient: catalogClient,
+		products:      newProductCache(cache),
+		shutdown:      ctx,
 	})
+	// Orders need the account and catalog services as well
+	deps := health.Checks{
+		"account": accountClient.Health,
+		"catalog": catalogClient.Health,
+	}
+	for name, check := range checks {
+		deps[name] = check
+	}
+	healthSrv := health.NewServer(ctx, pb.OrderService_ServiceDesc.ServiceName, deps)
+	defer healthSrv.Stop()
+	healthSrv.Register(grpcSrv)
 	reflection.Register(grpcSrv)
 
 	log.Printf("🚀 gRPC Order service running on port %d", port)
-	return grpcSrv.Serve(lis)
+	return graceful.ServeGRPC(ctx, grpcSrv, lis)
 }
 
 // PostOrder handles creating a new order
 func (s *grpcServer) PostOrder(ctx context.Context, req *pb.PostOrderRequest) (*pb.PostOrderResponse, error) {
-	// Convert request products to internal OrderProduct
-	products := convertRequestProtoToOrderProducts(req.Products)
+	if err := auth.RequireOwner(ctx, req.AccountId); err != nil {
+		return nil, err
+	}
+	// Convert request products to internal OrderProduct, one line per product
+	products := MergeProducts(convertRequestProtoToOrderProducts(req.Products))
+	if len(products) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "order must contain at least one product")
+	}
+	for _, p := range products {
+		if p.Quantity == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "quantity of product %q must be positive", p.ID)
+		}
+	}
+
+	if utf8.RuneCountInString(req.IdempotencyKey) > MaxIdempotencyKeyLength {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key must be at most %d characters", MaxIdempotencyKeyLength)
+	}
 
-	// Validate account exists
-	_, err := s.accountClient.GetAccount(ctx, req.AccountId)
+	// Replay of an earlier request returns the original order
+	existing, err := s.service.FindIdempotentOrder(ctx, req.AccountId, req.IdempotencyKey, products)
+	if err != nil {
+		log.Println("❌ Error checking idempotency key:", err)
+		return nil, postOrderError(err)
+	}
+	if existing != nil {
+		return &pb.PostOrderResponse{
+			Order: convertOrderToProto(existing),
+		}, nil
+	}
+
+	// Validate account exists and may place orders
+	acc, err := s.accountClient.GetAccount(ctx, req.AccountId)
 	if err != nil {
 		log.Println("❌ Error fetching account:", err)
 		return nil, err
 	}
+	if acc.Status == account.StatusDeactivated {
+		return nil, status.Errorf(codes.FailedPrecondition, "account %q is deactivated", req.AccountId)
+	}
 
 	// Fetch product details from catalog
 	productIDs := []string{}
@@ -81,6 +162,9 @@ func (s *grpcServer) PostOrder(ctx context.Context, req *pb.PostOrderRequest) (*
 		log.Println("❌ Error fetching products:", err)
 		return nil, err
 	}
+	if missing := MissingProductIDs(products, catalogProducts); len(missing) > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown product IDs: %s", strings.Join(missing, ", "))
+	}
 
 	// Merge quantities with catalog details
 	for i := range products {
@@ -94,22 +178,69 @@ func (s *grpcServer) PostOrder(ctx context.Context, req *pb.PostOrderRequest) (*
 		}
 	}
 
+	// Hold stock until the order is persisted
+	items := make([]catalog.StockItem, 0, len(products))
+	for _, p := range products {
+		items = append(items, catalog.StockItem{
+			ProductID: p.ID,
+			Quantity:  p.Quantity,
+		})
+	}
+	reservation, err := s.catalogClient.ReserveStock(ctx, items, 0)
+	if err != nil {
+		log.Println("❌ Error reserving stock:", err)
+		return nil, err
+	}
+
 	// Create order
-	order, err := s.service.PostOrder(ctx, req.AccountId, products)
+	order, err := s.service.PostOrder(ctx, req.AccountId, products, req.IdempotencyKey)
 	if err != nil {
-		log.Println("❌ Error posting order:", err)
-		return nil, errors.New("failed to post order")
+		s.releaseReservation(ctx, reservation.ID)
+		// A concurrent request with the same key created the order first
+		if errors.Is(err, ErrDuplicateIdempotencyKey) {
+			order, err = s.service.FindIdempotentOrder(ctx, req.AccountId, req.IdempotencyKey, products)
+		}
+		if err != nil {
+			log.Println("❌ Error posting order:", err)
+			return nil, postOrderError(err)
+		}
+		return &pb.PostOrderResponse{
+			Order: convertOrderToProto(order),
+		}, nil
+	}
+
+	// An uncommitted reservation would be swept and its stock sold again,
+	// so an order whose stock cannot be committed is given up
+	if err := s.commitReservation(ctx, reservation.ID); err != nil {
+		log.Printf("❌ Error committing reservation %s for order %s: %v", reservation.ID, order.ID, err)
+		s.abandonOrder(ctx, order.ID, reservation.ID)
+		return nil, status.Error(codes.Unavailable, "could not commit the stock of the order, try again")
 	}
 
 	// Convert to protobuf response
 	return &pb.PostOrderResponse{
-		Order: &pb.Order{
-			Id:         order.ID,
-			AccountId:  order.AccountID,
-			TotalPrice: order.TotalPrice,
-			CreatedAt:  timestamppb.New(order.CreatedAt),
-			Products:   convertOrderProductsToProto(order.Products),
-		},
+		Order: convertOrderToProto(order),
+	}, nil
+}
+
+// GetOrder fetches a single order by ID
+func (s *grpcServer) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
+	o, err := s.service.GetOrder(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "order %q not found", req.Id)
+		}
+		log.Println("❌ Error fetching order:", err)
+		return nil, unexpectedError(err, "failed to get order")
+	}
+
+	if err := s.fillProductDetails(ctx, o.Products); err != nil {
+		log.Println("❌ Error fetching product details:", err)
+		return nil, unexpectedError(err, "failed to get product details")
+	}
+
+	return &pb.GetOrderResponse{
+		Order: convertOrderToProto(o),
 	}, nil
 }
 
@@ -118,45 +249,386 @@ func (s *grpcServer) GetOrderForAccount(ctx context.Context, req *pb.GetOrderFor
 	orders, err := s.service.GetOrdersForAccount(ctx, req.AccountId)
 	if err != nil {
 		log.Println("❌ Error fetching orders:", err)
-		return nil, err
+		return nil, unexpectedError(err, "failed to get orders")
+	}
+
+	// Legacy rows have no product snapshot
+	if err := s.fillProductDetails(ctx, orderProducts(orders)...); err != nil {
+		log.Println("❌ Error fetching product details:", err)
+		return nil, unexpectedError(err, "failed to get product details")
 	}
 
 	var protoOrders []*pb.Order
 	for _, o := range orders {
-		// Fetch product details from catalog for each order
-		productIDs := []string{}
-		for _, p := range o.Products {
-			productIDs = append(productIDs, p.ID)
+		protoOrders = append(protoOrders, convertOrderToProto(&o))
+	}
+
+	return &pb.GetOrderForAccountResponse{Orders: protoOrders}, nil
+}
+
+// ListOrders pages through orders, optionally of a single account
+func (s *grpcServer) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	filter, err := convertProtoToOrderFilter(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sortBy, err := ParseOrderSort(req.Sort)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	page, err := s.service.ListOrders(ctx, filter, sortBy, req.After, req.First)
+	if err != nil {
+		log.Println("❌ Error listing orders:", err)
+		if errors.Is(err, cursor.ErrInvalid) || errors.Is(err, ErrInvalidFilter) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		return nil, unexpectedError(err, "failed to list orders")
+	}
 
-		catalogProducts, err := s.catalogClient.GetProducts(ctx, 0, 0, productIDs, "")
-		if err != nil {
+	// Legacy rows have no product snapshot
+	if err := s.fillProductDetails(ctx, orderProducts(page.Orders)...); err != nil {
+		log.Println("❌ Error fetching product details:", err)
+		return nil, unexpectedError(err, "failed to get product details")
+	}
+
+	protoOrders := make([]*pb.Order, 0, len(page.Orders))
+	for _, o := range page.Orders {
+		protoOrders = append(protoOrders, convertOrderToProto(&o))
+	}
+
+	return &pb.ListOrdersResponse{
+		Orders:      protoOrders,
+		Cursors:     page.Cursors,
+		HasNextPage: page.HasNextPage,
+	}, nil
+}
+
+// ListOrdersForAccounts fetches the first page of orders of several accounts
+// in one call
+func (s *grpcServer) ListOrdersForAccounts(ctx context.Context, req *pb.ListOrdersForAccountsRequest) (*pb.ListOrdersForAccountsResponse, error) {
+	query := req.Query
+	if query == nil {
+		query = &pb.ListOrdersRequest{}
+	}
+	if query.AccountId != "" || query.After != "" {
+		return nil, status.Error(codes.InvalidArgument, "query must not set accountId or after")
+	}
+	filter, err := convertProtoToOrderFilter(query)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sortBy, err := ParseOrderSort(query.Sort)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	pages, err := s.service.ListOrdersForAccounts(ctx, req.AccountIds, filter, sortBy, query.First)
+	if err != nil {
+		log.Println("❌ Error listing orders for accounts:", err)
+		if errors.Is(err, ErrInvalidFilter) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, unexpectedError(err, "failed to list orders")
+	}
+
+	// Legacy rows of all accounts are filled in one lookup
+	products := [][]OrderProduct{}
+	for _, page := range pages {
+		products = append(products, orderProducts(page.Orders)...)
+	}
+	if err := s.fillProductDetails(ctx, products...); err != nil {
+		log.Println("❌ Error fetching product details:", err)
+		return nil, unexpectedError(err, "failed to get product details")
+	}
+
+	resp := &pb.ListOrdersForAccountsResponse{}
+	for _, id := range req.AccountIds {
+		page, ok := pages[id]
+		if !ok {
+			continue
+		}
+		// Each account is answered once, even if requested twice
+		delete(pages, id)
+		protoOrders := make([]*pb.Order, 0, len(page.Orders))
+		for _, o := range page.Orders {
+			protoOrders = append(protoOrders, convertOrderToProto(&o))
+		}
+		resp.Accounts = append(resp.Accounts, &pb.ListOrdersForAccountsResponse_AccountOrders{
+			AccountId:   id,
+			Orders:      protoOrders,
+			Cursors:     page.Cursors,
+			HasNextPage: page.HasNextPage,
+		})
+	}
+	return resp, nil
+}
+
+// UpdateOrderStatus moves an order through its lifecycle
+func (s *grpcServer) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusRequest) (*pb.UpdateOrderStatusResponse, error) {
+	orderStatus, err := ParseOrderStatus(req.Status)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	o, err := s.service.UpdateOrderStatus(ctx, req.Id, orderStatus)
+	if err != nil {
+		log.Println("❌ Error updating order status:", err)
+		return nil, statusError(req.Id, err)
+	}
+
+	if err := s.fillProductDetails(ctx, o.Products); err != nil {
+		log.Println("❌ Error fetching product details:", err)
+		return nil, unexpectedError(err, "failed to get product details")
+	}
+
+	return &pb.UpdateOrderStatusResponse{
+		Order: convertOrderToProto(o),
+	}, nil
+}
+
+// CancelOrder cancels an order that has not shipped yet
+func (s *grpcServer) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error) {
+	existing, err := s.service.GetOrder(ctx, req.Id)
+	if err != nil {
+		return nil, statusError(req.Id, err)
+	}
+	if err := auth.RequireOwner(ctx, existing.AccountID); err != nil {
+		return nil, err
+	}
+
+	o, err := s.service.CancelOrder(ctx, req.Id)
+	if err != nil {
+		log.Println("❌ Error cancelling order:", err)
+		return nil, statusError(req.Id, err)
+	}
+
+	if err := s.fillProductDetails(ctx, o.Products); err != nil {
+		log.Println("❌ Error fetching product details:", err)
+		return nil, unexpectedError(err, "failed to get product details")
+	}
+
+	return &pb.CancelOrderResponse{
+		Order: convertOrderToProto(o),
+	}, nil
+}
+
+// WatchOrders streams order events until the client goes away
+func (s *grpcServer) WatchOrders(req *pb.WatchOrdersRequest, stream pb.OrderService_WatchOrdersServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	stop := context.AfterFunc(s.shutdown, cancel)
+	defer stop()
+	events, err := s.service.WatchOrders(ctx, req.OrderId, req.AccountId)
+	if err != nil {
+		log.Println("❌ Error watching orders:", err)
+		return unexpectedError(err, "failed to watch orders")
+	}
+
+	for event := range events {
+		// Legacy rows have no product snapshot
+		if err := s.fillProductDetails(ctx, event.Order.Products); err != nil {
 			log.Println("❌ Error fetching product details:", err)
-			return nil, err
+			return unexpectedError(err, "failed to get product details")
+		}
+		if err := stream.Send(&pb.OrderEvent{
+			Type:  string(event.Type),
+			Order: convertOrderToProto(&event.Order),
+		}); err != nil {
+			return err
 		}
+	}
+	if s.shutdown.Err() != nil && stream.Context().Err() == nil {
+		return status.Error(codes.Unavailable, "server shutting down, watch again")
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return status.Error(codes.ResourceExhausted, "watcher fell too far behind, watch again")
+}
+
+// Helper: commit a reservation, retrying failures. The order is stored by
+// then, so the commit outlives a cancelled request.
+func (s *grpcServer) commitReservation(ctx context.Context, id string) error {
+	ctx = context.WithoutCancel(ctx)
+	return retry.Do(
+		func() error {
+			ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			return s.catalogClient.CommitReservation(ctx, id)
+		},
+		retry.Attempts(3),
+		retry.Delay(100*time.Millisecond),
+		retry.LastErrorOnly(true),
+	)
+}
+
+// Helper: cancel an order whose reservation could not be committed and
+// return its stock
+func (s *grpcServer) abandonOrder(ctx context.Context, orderID string, reservationID string) {
+	cancelCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if _, err := s.service.CancelOrder(cancelCtx, orderID); err != nil {
+		log.Printf("❌ Error cancelling order %s: %v", orderID, err)
+	}
+	s.releaseReservation(ctx, reservationID)
+}
+
+// Helper: give back reserved stock. It runs even if the request was
+// cancelled, since the reservation would otherwise be held until it expires.
+func (s *grpcServer) releaseReservation(ctx context.Context, id string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.catalogClient.ReleaseReservation(ctx, id); err != nil {
+		log.Printf("❌ Error releasing reservation %s: %v", id, err)
+	}
+}
 
-		// Merge quantities with catalog details
-		for i := range o.Products {
-			for _, cp := range catalogProducts {
-				if o.Products[i].ID == cp.ID {
-					o.Products[i].Name = cp.Name
-					o.Products[i].Description = cp.Description
-					o.Products[i].Price = cp.Price
-					break
-				}
+// Helper: map order creation errors to gRPC status codes
+func postOrderError(err error) error {
+	switch {
+	case errors.Is(err, money.ErrCurrencyMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrIdempotencyConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return unexpectedError(err, "failed to post order")
+	}
+}
+
+// Helper: map status update errors to gRPC status codes
+func statusError(id string, err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Errorf(codes.NotFound, "order %q not found", id)
+	case errors.Is(err, ErrInvalidTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return unexpectedError(err, "order request failed")
+	}
+}
+
+// unexpectedError hides errors no case above knows, such as database
+// errors, behind a generic message. Statuses, as from the account and
+// catalog services, are passed on.
+func unexpectedError(err error, message string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, message+": timed out")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, message+": canceled")
+	default:
+		return status.Error(codes.Internal, message)
+	}
+}
+
+// Helper: fill name, description and price of order products written before
+// the purchase-time snapshot existed. Newer rows carry their own snapshot and
+// never hit the catalog. Products of all given orders are looked up together.
+func (s *grpcServer) fillProductDetails(ctx context.Context, orders ...[]OrderProduct) error {
+	productIDs := []string{}
+	for _, products := range orders {
+		for _, p := range products {
+			if p.Name == "" {
+				productIDs = append(productIDs, p.ID)
 			}
 		}
+	}
+	if len(productIDs) == 0 {
+		return nil
+	}
 
-		protoOrders = append(protoOrders, &pb.Order{
-			Id:         o.ID,
-			AccountId:  o.AccountID,
-			TotalPrice: o.TotalPrice,
-			CreatedAt:  timestamppb.New(o.CreatedAt),
-			Products:   convertOrderProductsToProto(o.Products),
-		})
+	catalogProducts, err := s.lookupProducts(ctx, productIDs)
+	if err != nil {
+		return err
 	}
 
-	return &pb.GetOrderForAccountResponse{Orders: protoOrders}, nil
+	// Merge quantities with catalog details
+	for _, products := range orders {
+		for i := range products {
+			if products[i].Name != "" {
+				continue
+			}
+			if cp, ok := catalogProducts[products[i].ID]; ok {
+				products[i].Name = cp.Name
+				products[i].Description = cp.Description
+				products[i].Price = cp.Price
+			}
+		}
+	}
+	return nil
+}
+
+// Helper: fetch catalog products by ID, each ID once. Recently fetched
+// products come from the cache; the rest are requested in chunks of
+// productBatchSize.
+func (s *grpcServer) lookupProducts(ctx context.Context, ids []string) (map[string]catalog.Product, error) {
+	found := make(map[string]catalog.Product, len(ids))
+	seen := make(map[string]bool, len(ids))
+	missing := []string{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if p, ok := s.products.get(id); ok {
+			found[id] = p
+			continue
+		}
+		missing = append(missing, id)
+	}
+
+	for start := 0; start < len(missing); start += productBatchSize {
+		chunk := missing[start:min(start+productBatchSize, len(missing))]
+		catalogProducts, err := s.catalogClient.GetProducts(ctx, 0, 0, chunk, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range catalogProducts {
+			found[p.ID] = p
+			s.products.put(p)
+		}
+	}
+	return found, nil
+}
+
+// Helper: the product lines of each order
+func orderProducts(orders []Order) [][]OrderProduct {
+	products := make([][]OrderProduct, len(orders))
+	for i, o := range orders {
+		products[i] = o.Products
+	}
+	return products
+}
+
+// Helper: convert the filters of a ListOrders request
+func convertProtoToOrderFilter(req *pb.ListOrdersRequest) (OrderFilter, error) {
+	filter := OrderFilter{
+		AccountID: req.AccountId,
+	}
+	if req.CreatedAfter != nil {
+		filter.CreatedAfter = req.CreatedAfter.AsTime()
+	}
+	if req.CreatedBefore != nil {
+		filter.CreatedBefore = req.CreatedBefore.AsTime()
+	}
+	for _, s := range req.Statuses {
+		orderStatus, err := ParseOrderStatus(s)
+		if err != nil {
+			return OrderFilter{}, err
+		}
+		filter.Statuses = append(filter.Statuses, orderStatus)
+	}
+	if req.MinTotal != nil {
+		minTotal := money.New(req.MinTotal.Amount, req.MinTotal.Currency)
+		filter.MinTotal = &minTotal
+	}
+	if req.MaxTotal != nil {
+		maxTotal := money.New(req.MaxTotal.Amount, req.MaxTotal.Currency)
+		filter.MaxTotal = &maxTotal
+	}
+	return filter, nil
 }
 
 // Helper: convert request products to internal OrderProduct
@@ -171,6 +643,18 @@ func convertRequestProtoToOrderProducts(protoProducts []*pb.PostOrderRequest_Ord
 	return products
 }
 
+// Helper: convert internal Order to protobuf Order
+func convertOrderToProto(o *Order) *pb.Order {
+	return &pb.Order{
+		Id:         o.ID,
+		AccountId:  o.AccountID,
+		TotalPrice: convertMoneyToProto(o.TotalPrice),
+		CreatedAt:  timestamppb.New(o.CreatedAt),
+		Status:     string(o.Status),
+		Products:   convertOrderProductsToProto(o.Products),
+	}
+}
+
 // Helper: convert internal OrderProduct to protobuf Order_OrderProduct
 func convertOrderProductsToProto(products []OrderProduct) []*pb.Order_OrderProduct {
 	protoProducts := make([]*pb.Order_OrderProduct, len(products))
@@ -180,8 +664,16 @@ func convertOrderProductsToProto(products []OrderProduct) []*pb.Order_OrderProdu
 			Name:        p.Name,
 			Description: p.Description,
 			Quantity:    p.Quantity,
-			Price:       p.Price,
+			Price:       convertMoneyToProto(p.Price),
 		}
 	}
 	return protoProducts
 }
+
+// Helper: convert Money to protobuf Money
+func convertMoneyToProto(m money.Money) *pb.Money {
+	return &pb.Money{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+	}
+}
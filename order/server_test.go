@@ -0,0 +1,33 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{ErrNotFound, codes.NotFound},
+		{ErrInvalidTransition, codes.FailedPrecondition},
+		{status.Error(codes.NotFound, `product "p1" not found`), codes.NotFound},
+		{fmt.Errorf("update: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{errors.New(`sql: no rows in result set`), codes.Internal},
+	}
+	for _, tt := range tests {
+		got := status.Convert(statusError("o1", tt.err))
+		if got.Code() != tt.code {
+			t.Errorf("%v: got %s, want %s", tt.err, got.Code(), tt.code)
+		}
+		if tt.code == codes.Internal && got.Message() != "order request failed" {
+			t.Errorf("%v: internal error leaks %q", tt.err, got.Message())
+		}
+	}
+}
@@ -2,58 +2,170 @@ package order
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
+	"github.com/pawan-sharma-12/go_microservices/money"
 	"github.com/segmentio/ksuid"
 )
 
 type Service interface {
-	PostOrder(ctx context.Context, accountID string, products []OrderProduct) (*Order, error)
+	PostOrder(ctx context.Context, accountID string, products []OrderProduct, idempotencyKey string) (*Order, error)
+	FindIdempotentOrder(ctx context.Context, accountID string, idempotencyKey string, products []OrderProduct) (*Order, error)
+	GetOrder(ctx context.Context, id string) (*Order, error)
 	GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error)
+	ListOrders(ctx context.Context, filter OrderFilter, sortBy OrderSort, after string, first uint64) (*OrderPage, error)
+	ListOrdersForAccounts(ctx context.Context, accountIDs []string, filter OrderFilter, sortBy OrderSort, first uint64) (map[string]*OrderPage, error)
+	UpdateOrderStatus(ctx context.Context, id string, status OrderStatus) (*Order, error)
+	CancelOrder(ctx context.Context, id string) (*Order, error)
+	WatchOrders(ctx context.Context, orderID string, accountID string) (<-chan OrderEvent, error)
 }
 
 type Order struct {
 	ID         string  `json:"id"`
 	CreatedAt  time.Time   `json:"created_at"`
 	AccountID  string  `json:"account_id"`
-	TotalPrice float64 `json:"total_price"`
+	TotalPrice money.Money `json:"total_price"`
+	Status     OrderStatus `json:"status"`
 	Products   []OrderProduct `json:"products"`
+	// IdempotencyKey and RequestHash identify a client request so retries
+	// return the original order
+	IdempotencyKey string `json:"-"`
+	RequestHash    string `json:"-"`
 }
 type OrderProduct struct {
 	ID 	  string `json:"id"`
 	Name 	string `json:"name"`
 	Description string `json:"description"`
-	Price 	  float64 `json:"price"`
+	Price 	  money.Money `json:"price"`
 	Quantity 	uint64 `json:"quantity"`
 
 }
 
+var (
+	ErrIdempotencyConflict = errors.New("idempotency key was already used for a different order")
+)
+
+// MaxIdempotencyKeyLength is the longest idempotency key orders can store
+const MaxIdempotencyKeyLength = 255
+
 type OrderService struct {
-	repo Repository
+	repo   Repository
+	events *broker
 }
 func NewService(repo Repository) Service {
 	return &OrderService{
-		repo: repo,
+		repo:   repo,
+		events: newBroker(),
 	}
 }
-func (s *OrderService) PostOrder(ctx context.Context, accountID string, products []OrderProduct) (*Order, error) {
-	var totalPrice float64
+func (s *OrderService) PostOrder(ctx context.Context, accountID string, products []OrderProduct, idempotencyKey string) (*Order, error) {
+	var totalPrice money.Money
 	for _, p := range products {
-		totalPrice += p.Price * float64(p.Quantity)
+		var err error
+		totalPrice, err = totalPrice.Add(p.Price.Mul(p.Quantity))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if totalPrice.Currency == "" {
+		totalPrice.Currency = money.DefaultCurrency
 	}
 	order := Order{
 		ID:         ksuid.New().String(),
 		CreatedAt:  time.Now().UTC(),
 		AccountID:  accountID,
 		TotalPrice: totalPrice,
+		Status:     StatusPending,
 		Products:   products,
 	}
+	if idempotencyKey != "" {
+		order.IdempotencyKey = idempotencyKey
+		order.RequestHash = requestHash(accountID, products)
+	}
+	// ErrDuplicateIdempotencyKey means a concurrent request with the same key
+	// won the race; callers look up its order with FindIdempotentOrder
 	if err := s.repo.PutOrder(ctx, order); err != nil {
 		return nil, err
 	}
+	s.events.publish(EventCreated, order)
 	return &order, nil
 }
 
+// FindIdempotentOrder returns the order previously created with the same
+// idempotency key, or nil if there is none. Reusing a key for a different
+// payload fails with ErrIdempotencyConflict.
+func (s *OrderService) FindIdempotentOrder(ctx context.Context, accountID string, idempotencyKey string, products []OrderProduct) (*Order, error) {
+	if idempotencyKey == "" {
+		return nil, nil
+	}
+	order, err := s.repo.GetOrderByIdempotencyKey(ctx, accountID, idempotencyKey)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.RequestHash != requestHash(accountID, products) {
+		return nil, ErrIdempotencyConflict
+	}
+	return order, nil
+}
+
+// requestHash fingerprints the client-supplied part of an order: the account
+// and the quantity of each product, independent of line order. Prices are
+// left out because they come from the catalog and may change between retries.
+func requestHash(accountID string, products []OrderProduct) string {
+	lines := make([]string, 0, len(products))
+	for _, p := range products {
+		lines = append(lines, fmt.Sprintf("%s:%d", p.ID, p.Quantity))
+	}
+	sort.Strings(lines)
+
+	h := sha256.New()
+	h.Write([]byte(accountID))
+	for _, l := range lines {
+		h.Write([]byte("\n" + l))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *OrderService) GetOrder(ctx context.Context, id string) (*Order, error) {
+	return s.repo.GetOrder(ctx, id)
+}
 
 func (s *OrderService) GetOrdersForAccount(ctx context.Context, accountID string) ([]Order, error) {
 	return s.repo.GetOrdersForAccount(ctx, accountID)
-}
\ No newline at end of file
+}
+
+// UpdateOrderStatus moves an order to a new status if the state machine allows it
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, id string, status OrderStatus) (*Order, error) {
+	order, err := s.repo.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !order.Status.CanTransition(status) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, order.Status, status)
+	}
+	if err := s.repo.UpdateOrderStatus(ctx, id, order.Status, status, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	order.Status = status
+	s.events.publish(EventUpdated, *order)
+	return order, nil
+}
+
+func (s *OrderService) CancelOrder(ctx context.Context, id string) (*Order, error) {
+	return s.UpdateOrderStatus(ctx, id, StatusCancelled)
+}
+
+// WatchOrders streams the orders created or updated by this service from now
+// on: those of one order, of one account's orders, or all if both IDs are
+// empty. The channel is closed when ctx is done or if the caller falls too
+// far behind.
+func (s *OrderService) WatchOrders(ctx context.Context, orderID string, accountID string) (<-chan OrderEvent, error) {
+	return s.events.subscribe(ctx, orderID, accountID), nil
+}
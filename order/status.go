@@ -0,0 +1,52 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+)
+
+type OrderStatus string
+
+const (
+	StatusPending   OrderStatus = "pending"
+	StatusPaid      OrderStatus = "paid"
+	StatusFulfilled OrderStatus = "fulfilled"
+	StatusShipped   OrderStatus = "shipped"
+	StatusDelivered OrderStatus = "delivered"
+	StatusCancelled OrderStatus = "cancelled"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("invalid order status")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+)
+
+// transitions lists the statuses an order may move to from each status.
+// Delivered and cancelled orders are final.
+var transitions = map[OrderStatus][]OrderStatus{
+	StatusPending:   {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusFulfilled, StatusCancelled},
+	StatusFulfilled: {StatusShipped, StatusCancelled},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {},
+	StatusCancelled: {},
+}
+
+// ParseOrderStatus validates a status string coming from a client
+func ParseOrderStatus(s string) (OrderStatus, error) {
+	status := OrderStatus(s)
+	if _, ok := transitions[status]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidStatus, s)
+	}
+	return status, nil
+}
+
+// CanTransition reports whether an order in status from may move to status to
+func (from OrderStatus) CanTransition(to OrderStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
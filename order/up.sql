@@ -13,3 +13,83 @@ CREATE TABLE IF NOT EXISTS order_products (
     quantity BIGINT NOT NULL,
     PRIMARY KEY (order_id, product_id)
 );
+
+-- Order status
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'paid', 'fulfilled', 'shipped', 'delivered', 'cancelled'));
+
+-- Order status transitions
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_id CHAR(27) NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status VARCHAR(16),
+    to_status VARCHAR(16) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_id_idx ON order_status_history (order_id, changed_at);
+
+-- Purchase-time product snapshot. Rows written before this migration keep
+-- empty values and are filled from the catalog when read.
+ALTER TABLE order_products
+    ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS price DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- Store prices as integer minor units with an ISO 4217 currency code.
+-- Existing float amounts are treated as USD and scaled to its minor unit.
+-- The backfills only run while the float columns are still there, so the
+-- migration can be applied again.
+BEGIN;
+
+-- Same exponents as money.Exponent
+CREATE OR REPLACE FUNCTION pg_temp.minor_unit_scale(currency TEXT) RETURNS NUMERIC AS $$
+    SELECT POWER(10, CASE currency
+        WHEN 'CLP' THEN 0 WHEN 'ISK' THEN 0 WHEN 'JPY' THEN 0 WHEN 'KRW' THEN 0 WHEN 'VND' THEN 0
+        WHEN 'BHD' THEN 3 WHEN 'IQD' THEN 3 WHEN 'JOD' THEN 3 WHEN 'KWD' THEN 3 WHEN 'OMR' THEN 3 WHEN 'TND' THEN 3
+        ELSE 2
+    END)::NUMERIC
+$$ LANGUAGE SQL IMMUTABLE;
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS total_amount BIGINT,
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'orders' AND column_name = 'total_price') THEN
+        UPDATE orders SET total_amount = ROUND(total_price * pg_temp.minor_unit_scale(currency)) WHERE total_amount IS NULL;
+    END IF;
+END $$;
+ALTER TABLE orders
+    ALTER COLUMN total_amount SET NOT NULL,
+    DROP COLUMN IF EXISTS total_price;
+
+ALTER TABLE order_products
+    ADD COLUMN IF NOT EXISTS price_amount BIGINT,
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'order_products' AND column_name = 'price') THEN
+        UPDATE order_products SET price_amount = ROUND(price * pg_temp.minor_unit_scale(currency)) WHERE price_amount IS NULL;
+    END IF;
+END $$;
+ALTER TABLE order_products
+    ALTER COLUMN price_amount SET NOT NULL,
+    DROP COLUMN IF EXISTS price;
+
+COMMIT;
+
+-- Idempotency keys for order creation, unique per account
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS request_hash CHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS orders_account_idempotency_key_key ON orders (account_id, idempotency_key);
+
+-- Keyset pagination of order listings, per account and overall
+CREATE INDEX IF NOT EXISTS orders_account_created_at_idx ON orders (account_id, created_at, id);
+CREATE INDEX IF NOT EXISTS orders_account_total_idx ON orders (account_id, total_amount, id);
+CREATE INDEX IF NOT EXISTS orders_created_at_idx ON orders (created_at, id);
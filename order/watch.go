@@ -0,0 +1,86 @@
+package order
+
+import (
+	"context"
+	"sync"
+)
+
+type OrderEventType string
+
+const (
+	EventCreated OrderEventType = "created"
+	EventUpdated OrderEventType = "updated"
+)
+
+// OrderEvent reports an order that was created or whose status changed
+type OrderEvent struct {
+	Type  OrderEventType
+	Order Order
+}
+
+// watchBuffer is how many events a watcher may lag behind before it is
+// dropped
+const watchBuffer = 64
+
+// broker fans order events out to the watchers of this process
+type broker struct {
+	mu       sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+type watcher struct {
+	orderID   string
+	accountID string
+	events    chan OrderEvent
+}
+
+func newBroker() *broker {
+	return &broker{watchers: make(map[*watcher]struct{})}
+}
+
+// subscribe returns the events of the order with orderID and of the orders of
+// accountID; empty IDs match all orders. The channel is closed when ctx is
+// done, or early if the watcher falls more than watchBuffer events behind.
+func (b *broker) subscribe(ctx context.Context, orderID string, accountID string) <-chan OrderEvent {
+	w := &watcher{
+		orderID:   orderID,
+		accountID: accountID,
+		events:    make(chan OrderEvent, watchBuffer),
+	}
+	b.mu.Lock()
+	b.watchers[w] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.remove(w)
+	}()
+	return w.events
+}
+
+func (b *broker) publish(eventType OrderEventType, o Order) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for w := range b.watchers {
+		if (w.orderID != "" && w.orderID != o.ID) || (w.accountID != "" && w.accountID != o.AccountID) {
+			continue
+		}
+		select {
+		case w.events <- OrderEvent{Type: eventType, Order: copyOrder(o)}:
+		default:
+			// A watcher that cannot keep up is dropped rather than
+			// silently missing events
+			delete(b.watchers, w)
+			close(w.events)
+		}
+	}
+}
+
+func (b *broker) remove(w *watcher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.watchers[w]; ok {
+		delete(b.watchers, w)
+		close(w.events)
+	}
+}
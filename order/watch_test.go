@@ -0,0 +1,69 @@
+package order
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pawan-sharma-12/go_microservices/money"
+)
+
+func TestWatchOrders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewService(NewMemoryRepository())
+	products := []OrderProduct{{ID: "p1", Name: "lamp", Price: money.New(500, "USD"), Quantity: 1}}
+
+	all, _ := s.WatchOrders(ctx, "", "")
+	ofAccount, _ := s.WatchOrders(ctx, "", "alice")
+
+	o, err := s.PostOrder(ctx, "alice", products, "")
+	if err != nil {
+		t.Fatalf("PostOrder: %v", err)
+	}
+	if _, err := s.PostOrder(ctx, "bob", products, ""); err != nil {
+		t.Fatalf("PostOrder: %v", err)
+	}
+	ofOrder, _ := s.WatchOrders(ctx, o.ID, "")
+	if _, err := s.UpdateOrderStatus(ctx, o.ID, StatusPaid); err != nil {
+		t.Fatalf("UpdateOrderStatus: %v", err)
+	}
+
+	expect := func(name string, events <-chan OrderEvent, want ...OrderEventType) {
+		t.Helper()
+		for _, eventType := range want {
+			e := <-events
+			if e.Type != eventType {
+				t.Errorf("%s: got %s event of order %s, want %s", name, e.Type, e.Order.ID, eventType)
+			}
+		}
+		select {
+		case e := <-events:
+			t.Errorf("%s: unexpected %s event of order %s", name, e.Type, e.Order.ID)
+		default:
+		}
+	}
+	expect("all", all, EventCreated, EventCreated, EventUpdated)
+	expect("account", ofAccount, EventCreated, EventUpdated)
+	expect("order", ofOrder, EventUpdated)
+
+	cancel()
+	if _, ok := <-all; ok {
+		t.Error("events channel still open after the context ended")
+	}
+}
+
+func TestWatchOrdersDropsSlowWatcher(t *testing.T) {
+	b := newBroker()
+	events := b.subscribe(context.Background(), "", "")
+	for i := 0; i <= watchBuffer; i++ {
+		b.publish(EventUpdated, Order{ID: "o"})
+	}
+
+	n := 0
+	for range events {
+		n++
+	}
+	if n != watchBuffer {
+		t.Errorf("got %d events before the channel closed, want %d", n, watchBuffer)
+	}
+}
@@ -0,0 +1,147 @@
+// Package tracing follows requests across the gateway, the services and
+// their datastores with OpenTelemetry. Trace context travels in the W3C
+// traceparent header over HTTP and in gRPC metadata; spans are exported over
+// OTLP when an endpoint is configured.
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelsql"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// flushTimeout bounds the export of the spans left at shutdown
+const flushTimeout = 5 * time.Second
+
+// Setup installs trace context propagation and, when
+// OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set,
+// a tracer provider exporting spans of service over OTLP/gRPC. The other
+// standard OTEL_* variables, such as OTEL_TRACES_SAMPLER or
+// OTEL_EXPORTER_OTLP_INSECURE, apply too. The returned function flushes the
+// spans left; call it once the servers have stopped.
+func Setup(ctx context.Context, service string) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		log.Println("⚠️ No OTLP endpoint set, spans are not exported")
+		return func() {}, nil
+	}
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("OTLP exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("trace resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(requestsOnly{sdktrace.NewBatchSpanProcessor(exporter)}),
+	)
+	otel.SetTracerProvider(tp)
+	log.Printf("🔭 Exporting spans of %s over OTLP", service)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		defer cancel()
+		if err := tp.Shutdown(ctx); err != nil {
+			log.Printf("⚠️ Could not flush spans: %v", err)
+		}
+	}, nil
+}
+
+// requestsOnly drops the spans of calls made outside any request, such as
+// health checks of dependencies or the reservation sweeper, which would
+// otherwise bury the traces of requests
+type requestsOnly struct {
+	sdktrace.SpanProcessor
+}
+
+func (p requestsOnly) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.Parent().IsValid() && s.SpanKind() == trace.SpanKindClient {
+		return
+	}
+	p.SpanProcessor.OnEnd(s)
+}
+
+// NewRecorder makes the global tracer provider record spans in memory, for
+// tests. Instrumentation picks its tracer when created, so servers and
+// clients must be made after. The returned function stops recording and
+// restores the previous provider.
+func NewRecorder() (*tracetest.SpanRecorder, func()) {
+	previous := otel.GetTracerProvider()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	return recorder, func() {
+		tp.Shutdown(context.Background())
+		otel.SetTracerProvider(previous)
+	}
+}
+
+// ServerOption makes a gRPC server continue the traces of incoming calls
+// and record a span for each. Health checks are left out.
+func ServerOption() grpc.ServerOption {
+	return grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck()))))
+}
+
+// DialOption makes a gRPC client record a span for each call and pass the
+// trace on
+func DialOption() grpc.DialOption {
+	return grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck()))))
+}
+
+// OpenPostgres opens a database whose queries, statements and transactions
+// are recorded as spans
+func OpenPostgres(url string) (*sql.DB, error) {
+	return otelsql.Open("postgres", url, otelsql.WithDBSystem("postgresql"))
+}
+
+// ElasticsearchClient returns an HTTP client recording a span for each
+// request to Elasticsearch
+func ElasticsearchClient() *http.Client {
+	return &http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport,
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return "elasticsearch " + r.Method + " " + elasticEndpoint(r.URL.Path)
+			}),
+			otelhttp.WithSpanOptions(trace.WithAttributes(attribute.String("db.system", "elasticsearch"))),
+		),
+	}
+}
+
+// Helper: the path of an Elasticsearch request up to its endpoint, e.g.
+// /catalog/_doc for /catalog/_doc/<id>, so that span names stay few
+func elasticEndpoint(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, "_") {
+			return "/" + strings.Join(segments[:i+1], "/")
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testServer struct {
+	testpb.UnimplementedTestServiceServer
+}
+
+func (testServer) EmptyCall(context.Context, *testpb.Empty) (*testpb.Empty, error) {
+	return &testpb.Empty{}, nil
+}
+
+func TestPropagation(t *testing.T) {
+	recorder, stop := NewRecorder()
+	defer stop()
+
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer(ServerOption())
+	testpb.RegisterTestServiceServer(srv, testServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		DialOption(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+	_, err = testpb.NewTestServiceClient(conn).EmptyCall(ctx, &testpb.Empty{})
+	span.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[trace.SpanKind]bool{}
+	for _, s := range recorder.Ended() {
+		if s.SpanContext().TraceID() != span.SpanContext().TraceID() {
+			t.Errorf("%s: not in the trace of the request", s.Name())
+		}
+		kinds[s.SpanKind()] = true
+	}
+	if !kinds[trace.SpanKindClient] || !kinds[trace.SpanKindServer] {
+		t.Errorf("got spans of kinds %v, want client and server", kinds)
+	}
+}
+
+func TestRequestsOnly(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	p := requestsOnly{recorder}
+
+	ended := func(name string, kind trace.SpanKind, parent bool) {
+		stub := tracetest.SpanStub{Name: name, SpanKind: kind}
+		if parent {
+			stub.Parent = trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{1},
+				SpanID:  trace.SpanID{1},
+			})
+		}
+		p.OnEnd(stub.Snapshot())
+	}
+	ended("health probe", trace.SpanKindClient, false)
+	ended("query", trace.SpanKindClient, true)
+	ended("request", trace.SpanKindServer, false)
+
+	var names []string
+	for _, s := range recorder.Ended() {
+		names = append(names, s.Name())
+	}
+	if len(names) != 2 || names[0] != "query" || names[1] != "request" {
+		t.Errorf("got %v, want [query request]", names)
+	}
+}
+
+func TestElasticEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"/catalog/_doc/42":     "/catalog/_doc",
+		"/catalog/_search":     "/catalog/_search",
+		"/_mget":               "/_mget",
+		"/_cluster/health":     "/_cluster",
+		"/catalog":             "/catalog",
+		"/catalog/_update/42/": "/catalog/_update",
+	}
+	for path, want := range tests {
+		if got := elasticEndpoint(path); got != want {
+			t.Errorf("elasticEndpoint(%q) = %q, want %q", path, got, want)
+		}
+	}
+}